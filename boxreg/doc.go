@@ -0,0 +1,12 @@
+/*
+Package boxreg refines detections by bounding-box regression.
+
+A linear function of the feature window of a detection
+predicts the offset of the object's center
+and the log of the ratio of its width and height,
+as in Girshick, Donahue, Darrell and Malik,
+"Rich feature hierarchies for accurate object detection and semantic segmentation" (2014).
+The function is learned by ridge regression
+from detections which were validated as true positives.
+*/
+package boxreg
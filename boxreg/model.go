@@ -0,0 +1,114 @@
+package boxreg
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/feat"
+	"github.com/jvlmdr/go-cv/imsamp"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/nfnt/resize"
+)
+
+// Model predicts the rectangle of an object from a detection.
+type Model struct {
+	// Region from which features are computed.
+	// The detection is mapped to the interior.
+	PixelShape detect.PadRect
+	// Size of the feature window.
+	FeatSize image.Point
+	Channels int
+	// Weights and bias which predict each element of the offset.
+	Weights [4][]float64
+	Bias    [4]float64
+}
+
+// Offset describes the transformation from a detection to an object.
+// The translation is relative to the width and height of the detection.
+// 	X = (x_obj - x_det) / w_det,
+// 	Y = (y_obj - y_det) / h_det,
+// 	LogW = log(w_obj / w_det),
+// 	LogH = log(h_obj / h_det),
+// where (x, y) is the center of each rectangle.
+type Offset struct {
+	X, Y, LogW, LogH float64
+}
+
+func (t Offset) vec() [4]float64 { return [4]float64{t.X, t.Y, t.LogW, t.LogH} }
+
+// OffsetBetween computes the offset from a detection to an object.
+func OffsetBetween(det, obj image.Rectangle) Offset {
+	x, y, w, h := box(det)
+	xo, yo, wo, ho := box(obj)
+	return Offset{(xo - x) / w, (yo - y) / h, math.Log(wo / w), math.Log(ho / h)}
+}
+
+// Apply transforms a detection by the offset.
+func (t Offset) Apply(det image.Rectangle) image.Rectangle {
+	x, y, w, h := box(det)
+	x, y = x+w*t.X, y+h*t.Y
+	w, h = w*math.Exp(t.LogW), h*math.Exp(t.LogH)
+	return image.Rect(round(x-w/2), round(y-h/2), round(x+w/2), round(y+h/2))
+}
+
+// Returns center and size.
+func box(r image.Rectangle) (x, y, w, h float64) {
+	x = float64(r.Min.X+r.Max.X) / 2
+	y = float64(r.Min.Y+r.Max.Y) / 2
+	return x, y, float64(r.Dx()), float64(r.Dy())
+}
+
+func round(x float64) int {
+	return int(math.Floor(x + 0.5))
+}
+
+// Predict computes the offset of the object from the feature window of a detection.
+func (m *Model) Predict(x *rimg64.Multi) (Offset, error) {
+	if !x.Size().Eq(m.FeatSize) || x.Channels != m.Channels {
+		return Offset{}, fmt.Errorf("wrong feature size: want %v x %d, got %v x %d", m.FeatSize, m.Channels, x.Size(), x.Channels)
+	}
+	var t [4]float64
+	for k := range t {
+		t[k] = dot(m.Weights[k], x.Elems) + m.Bias[k]
+	}
+	return Offset{t[0], t[1], t[2], t[3]}, nil
+}
+
+// Apply refines the rectangle of every detection in an image.
+// It should be called after non-max suppression.
+// The scores and order of the detections are unchanged.
+func (m *Model) Apply(im image.Image, dets []detect.Det, phi feat.Image, interp resize.InterpolationFunction) ([]detect.Det, error) {
+	out := make([]detect.Det, len(dets))
+	for i, det := range dets {
+		x, err := Feat(im, det.Rect, m.PixelShape, phi, interp)
+		if err != nil {
+			return nil, err
+		}
+		t, err := m.Predict(x)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = detect.Det{det.Score, t.Apply(det.Rect)}
+	}
+	return out, nil
+}
+
+// Feat computes the feature window of a detection.
+// The region around the detection is cropped from the image
+// and resized to PixelShape.Size before the transform is applied.
+func Feat(im image.Image, rect image.Rectangle, shape detect.PadRect, phi feat.Image, interp resize.InterpolationFunction) (*rimg64.Multi, error) {
+	_, fit := detect.FitRect(rect, shape, "area")
+	crop := imsamp.Rect(im, fit, imsamp.Continue)
+	warp := resize.Resize(uint(shape.Size.X), uint(shape.Size.Y), crop, interp)
+	return phi.Apply(warp)
+}
+
+func dot(x, y []float64) float64 {
+	var s float64
+	for i := range x {
+		s += x[i] * y[i]
+	}
+	return s
+}
@@ -0,0 +1,143 @@
+package boxreg
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/feat"
+	"github.com/jvlmdr/go-cv/internal/linalg"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/nfnt/resize"
+)
+
+// Example is a detection and the object which it matched.
+type Example struct {
+	Rect image.Rectangle
+	Ref  image.Rectangle
+	Feat *rimg64.Multi
+}
+
+// Examples extracts the true positives from a validated image
+// whose intersection-over-union with their reference is at least minIOU.
+func Examples(im image.Image, val *detect.ValImage, minIOU float64, shape detect.PadRect, phi feat.Image, interp resize.InterpolationFunction) ([]Example, error) {
+	var examples []Example
+	for _, det := range val.Dets {
+		if !det.True || detect.IOU(det.Rect, det.Ref) < minIOU {
+			continue
+		}
+		x, err := Feat(im, det.Rect, shape, phi, interp)
+		if err != nil {
+			return nil, err
+		}
+		examples = append(examples, Example{det.Rect, det.Ref, x})
+	}
+	return examples, nil
+}
+
+// Train learns a model by ridge regression.
+// The objective for each element of the offset is
+// 	lambda ||w||^2 + sum_i (w' x_i + b - t_i)^2,
+// where the bias is not regularized.
+// The system is solved in the primal or the dual,
+// whichever has fewer variables.
+func Train(examples []Example, shape detect.PadRect, lambda float64) (*Model, error) {
+	if len(examples) == 0 {
+		return nil, errors.New("no examples")
+	}
+	if !(lambda > 0) {
+		return nil, fmt.Errorf("lambda must be positive: %g", lambda)
+	}
+	size, channels := examples[0].Feat.Size(), examples[0].Feat.Channels
+	n, d := len(examples), len(examples[0].Feat.Elems)
+	// Centered features and targets.
+	x := make([][]float64, n)
+	y := make([][4]float64, n)
+	var (
+		xmean = make([]float64, d)
+		ymean [4]float64
+	)
+	for i, e := range examples {
+		if !e.Feat.Size().Eq(size) || e.Feat.Channels != channels {
+			return nil, fmt.Errorf("example %d: different feature size: %v x %d, %v x %d", i, e.Feat.Size(), e.Feat.Channels, size, channels)
+		}
+		x[i] = append([]float64(nil), e.Feat.Elems...)
+		y[i] = OffsetBetween(e.Rect, e.Ref).vec()
+		for j := range xmean {
+			xmean[j] += x[i][j] / float64(n)
+		}
+		for k := range ymean {
+			ymean[k] += y[i][k] / float64(n)
+		}
+	}
+	for i := range x {
+		for j := range x[i] {
+			x[i][j] -= xmean[j]
+		}
+		for k := range y[i] {
+			y[i][k] -= ymean[k]
+		}
+	}
+
+	var w [4][]float64
+	if d <= n {
+		// Primal: (X' X + lambda I) w = X' y.
+		a := make([][]float64, d)
+		for p := range a {
+			a[p] = make([]float64, d)
+			a[p][p] = lambda
+		}
+		for _, xi := range x {
+			for p := range a {
+				for q := 0; q <= p; q++ {
+					a[p][q] += xi[p] * xi[q]
+				}
+			}
+		}
+		if err := linalg.Cholesky(a); err != nil {
+			return nil, err
+		}
+		for k := range w {
+			w[k] = make([]float64, d)
+			for i, xi := range x {
+				for j := range xi {
+					w[k][j] += xi[j] * y[i][k]
+				}
+			}
+			linalg.CholSolve(a, w[k])
+		}
+	} else {
+		// Dual: w = X' alpha, (X X' + lambda I) alpha = y.
+		a := make([][]float64, n)
+		for i := range a {
+			a[i] = make([]float64, n)
+			for j := 0; j <= i; j++ {
+				a[i][j] = dot(x[i], x[j])
+			}
+			a[i][i] += lambda
+		}
+		if err := linalg.Cholesky(a); err != nil {
+			return nil, err
+		}
+		for k := range w {
+			alpha := make([]float64, n)
+			for i := range alpha {
+				alpha[i] = y[i][k]
+			}
+			linalg.CholSolve(a, alpha)
+			w[k] = make([]float64, d)
+			for i, xi := range x {
+				for j := range xi {
+					w[k][j] += alpha[i] * xi[j]
+				}
+			}
+		}
+	}
+
+	m := &Model{PixelShape: shape, FeatSize: size, Channels: channels, Weights: w}
+	for k := range w {
+		m.Bias[k] = ymean[k] - dot(w[k], xmean)
+	}
+	return m, nil
+}
@@ -0,0 +1,111 @@
+package boxreg_test
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/boxreg"
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/featset"
+	"github.com/nfnt/resize"
+)
+
+// Generates a noisy image containing a bright ring.
+// Returns the image and the bounding box of the ring.
+func ringImage(size, obj int, r *rand.Rand) (image.Image, image.Rectangle) {
+	im := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range im.Pix {
+		im.Pix[i] = uint8(r.Intn(64))
+	}
+	pos := image.Pt(obj/2+r.Intn(size-2*obj), obj/2+r.Intn(size-2*obj))
+	box := image.Rectangle{pos, pos.Add(image.Pt(obj, obj))}
+	inner := box.Inset(obj / 4)
+	for x := box.Min.X; x < box.Max.X; x++ {
+		for y := box.Min.Y; y < box.Max.Y; y++ {
+			if !image.Pt(x, y).In(inner) {
+				im.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return im, box
+}
+
+// Perturbs the position and size of a rectangle.
+func perturb(box image.Rectangle, jitter int, r *rand.Rand) image.Rectangle {
+	d := func() int { return r.Intn(2*jitter+1) - jitter }
+	return image.Rect(box.Min.X+d(), box.Min.Y+d(), box.Max.X+d(), box.Max.Y+d())
+}
+
+func TestTrain(t *testing.T) {
+	const (
+		size   = 48
+		obj    = 16
+		jitter = 3
+	)
+	r := rand.New(rand.NewSource(1))
+	shape := detect.PadRect{Size: image.Pt(12, 12), Int: image.Rect(2, 2, 10, 10)}
+	phi := new(featset.Gray)
+
+	var examples []boxreg.Example
+	for i := 0; i < 400; i++ {
+		im, box := ringImage(size, obj, r)
+		val := &detect.ValImage{Dets: []detect.ValDet{
+			{detect.Det{1, perturb(box, jitter, r)}, detect.Val{True: true, Ref: box}},
+			// False positive.
+			{detect.Det{0, image.Rect(0, 0, obj, obj)}, detect.Val{}},
+		}}
+		ex, err := boxreg.Examples(im, val, 0, shape, phi, resize.Bilinear)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ex) != 1 {
+			t.Fatalf("number of examples: want 1, got %d", len(ex))
+		}
+		examples = append(examples, ex...)
+	}
+	model, err := boxreg.Train(examples, shape, 1e3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check that the model survives serialization.
+	buf, err := json.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model = new(boxreg.Model)
+	if err := json.Unmarshal(buf, model); err != nil {
+		t.Fatal(err)
+	}
+
+	var before, after float64
+	const n = 100
+	for i := 0; i < n; i++ {
+		im, box := ringImage(size, obj, r)
+		dets := []detect.Det{{2, perturb(box, jitter, r)}}
+		refined, err := model.Apply(im, dets, phi, resize.Bilinear)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if refined[0].Score != dets[0].Score {
+			t.Errorf("score changed: want %g, got %g", dets[0].Score, refined[0].Score)
+		}
+		before += detect.IOU(dets[0].Rect, box) / n
+		after += detect.IOU(refined[0].Rect, box) / n
+	}
+	t.Logf("mean IOU: before %.3f, after %.3f", before, after)
+	if after <= before {
+		t.Errorf("regression did not improve mean IOU: before %.3f, after %.3f", before, after)
+	}
+}
+
+func TestOffset(t *testing.T) {
+	det := image.Rect(10, 20, 30, 60)
+	obj := image.Rect(14, 16, 38, 56)
+	if got := boxreg.OffsetBetween(det, obj).Apply(det); !got.Eq(obj) {
+		t.Errorf("want %v, got %v", obj, got)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
+
+	"github.com/jvlmdr/go-cv/featset"
+	"github.com/jvlmdr/go-cv/lda"
+	"github.com/jvlmdr/go-file/fileutil"
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s [flags] transform.json images.txt stats.json\n", os.Args[0])
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Estimates the mean and spatial autocovariance of a feature transform")
+		fmt.Fprintln(os.Stderr, "over a list of background images, one file name per line.")
+		fmt.Fprintln(os.Stderr)
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	band := flag.Int("band", 10, "Maximum offset in feature pixels. Limits the size of templates.")
+	flag.Parse()
+	if flag.NArg() != 3 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	var (
+		transformFile = flag.Arg(0)
+		listFile      = flag.Arg(1)
+		statsFile     = flag.Arg(2)
+	)
+
+	var transform *featset.ImageMarshaler
+	if err := fileutil.LoadJSON(transformFile, &transform); err != nil {
+		log.Fatalln("load transform:", err)
+	}
+	imFiles, err := fileutil.LoadLines(listFile)
+	if err != nil {
+		log.Fatalln("load image list:", err)
+	}
+
+	accum := lda.NewAccum(transform.Channels(), *band)
+	for _, imFile := range imFiles {
+		im, err := loadImage(imFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		x, err := transform.Apply(im)
+		if err != nil {
+			log.Fatalln("feature transform:", err)
+		}
+		if x == nil {
+			log.Printf("skip image too small for transform: %s %v", imFile, im.Bounds().Size())
+			continue
+		}
+		if err := accum.Add(x); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	stats, err := accum.Stats()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := fileutil.SaveJSON(statsFile, stats); err != nil {
+		log.Fatalln("save statistics:", err)
+	}
+}
+
+func loadImage(fname string) (image.Image, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	im, _, err := image.Decode(file)
+	return im, err
+}
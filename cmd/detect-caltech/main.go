@@ -12,6 +12,7 @@ import (
 	"os"
 	"path"
 
+	"github.com/jvlmdr/go-cv/dataset/caltechped"
 	"github.com/jvlmdr/go-cv/detect"
 	"github.com/jvlmdr/go-cv/feat"
 	_ "github.com/jvlmdr/go-cv/hog"
@@ -39,7 +40,8 @@ func main() {
 		margin   = flag.Int("margin", 0, "Spatial bin parameter to HOG.")
 		// Validation options.
 		minValIOU      = flag.Float64("min-val-iou", 0.5, "Minimum IOU for a detection to be validated.")
-		minIgnoreCover = flag.Float64("min-ignore-cover", 0, "Minimum that a detection must be covered by an ignore region to be ignored.")
+		minIgnoreCover = flag.Float64("min-ignore-cover", 0.5, "Minimum that a detection must be covered by an ignore region to be ignored.")
+		aspect         = flag.Float64("aspect", 0.41, "Aspect ratio to which references and detections are standardized. Zero means no change.")
 		// Display options.
 		numShow = flag.Int("num-show", 4, "Number of detections to show per image")
 	)
@@ -80,16 +82,22 @@ func main() {
 		SupprFilter: detect.SupprFilter{MaxNum: 0, Overlap: overlap},
 	}
 
+	evalOpts := caltechped.DefaultEvalOpts()
+	evalOpts.Aspect = *aspect
+	evalOpts.MinIOU = *minValIOU
+	evalOpts.MinIgnoreCover = *minIgnoreCover
+
 	var val *detect.ValSet
 	err = fileutil.Cache(&val, "val-set.json", func() (*detect.ValSet, error) {
-		return testAll(dataset, *dir, tmpl, opts, *minValIOU, *minIgnoreCover, *numShow)
+		return testAll(dataset, *dir, tmpl, opts, evalOpts, *numShow)
 	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Printf("log-average miss rate: %.4g\n", caltechped.LogAvgMissRate(val))
 }
 
-func testAll(dataset *Dataset, dir string, tmpl *detect.FeatTmpl, opts detect.MultiScaleOpts, minValIOU, minIgnoreCover float64, numShow int) (*detect.ValSet, error) {
+func testAll(dataset *Dataset, dir string, tmpl *detect.FeatTmpl, opts detect.MultiScaleOpts, evalOpts caltechped.EvalOpts, numShow int) (*detect.ValSet, error) {
 	// Load each image and perform multi-scale detection.
 	rootDir := path.Join(dir, "data-"+dataset.Dir)
 	var vals []*detect.ValSet
@@ -133,7 +141,10 @@ func testAll(dataset *Dataset, dir string, tmpl *detect.FeatTmpl, opts detect.Mu
 					return nil, err
 				}
 				// Perform multi-scale detection.
-				dets := detect.MultiScale(im, tmpl, opts)
+				dets, err := detect.MultiScale(im, tmpl.Scorer, tmpl.PixelShape, opts)
+				if err != nil {
+					return nil, fmt.Errorf("detect: %v", err)
+				}
 				// Save detections for each image to file.
 				resFile := path.Join(resDir, fmt.Sprintf("I%05d.txt", frame))
 				if err := saveDets(resFile, dets); err != nil {
@@ -141,12 +152,11 @@ func testAll(dataset *Dataset, dir string, tmpl *detect.FeatTmpl, opts detect.Mu
 				}
 				// Load annotations and validate detections.
 				annotFile := path.Join(annotDir, fmt.Sprintf("I%05d.txt", frame))
-				annot, err := loadAnnot(annotFile)
+				annot, err := caltechped.LoadAnnot(annotFile)
 				if err != nil {
 					return nil, fmt.Errorf("load annotations: %v", err)
 				}
-				refs, ignore := Rects(annot, Reasonable())
-				val := detect.Validate(dets, refs, ignore, minValIOU, minIgnoreCover)
+				val := caltechped.Validate(dets, annot, evalOpts)
 				vals = append(vals, val.Set())
 				// Save visualization of detections.
 				visFile := path.Join(visDir, fmt.Sprintf("I%05d.jpg", frame))
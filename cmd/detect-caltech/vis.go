@@ -17,6 +17,9 @@ func visDets(dst, src string, val *detect.ValImage, n int) error {
 	args = append(args, "-undercolor", "#00000080")
 	// Annotate highest scoring annotations.
 	for i, det := range val.Dets {
+		if det.Ignore {
+			continue
+		}
 		if i >= n && !det.True {
 			continue
 		}
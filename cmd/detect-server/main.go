@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"os"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/feat"
+	"github.com/jvlmdr/go-cv/featset"
+	_ "github.com/jvlmdr/go-cv/hog"
+	"github.com/jvlmdr/go-cv/imsamp"
+	"github.com/jvlmdr/go-file/fileutil"
+	"github.com/nfnt/resize"
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s [flags] tmpl.(gob|json) transform.json\n", os.Args[0])
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Serves multi-scale detection with a template over HTTP.")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "POST /detect")
+		fmt.Fprintln(os.Stderr, "\tImage in the request body or in the multipart form field \"image\".")
+		fmt.Fprintln(os.Stderr, "\tOptional query parameters max-iou, max-num and min-score")
+		fmt.Fprintln(os.Stderr, "\toverride the defaults for one request.")
+		fmt.Fprintln(os.Stderr, "\tResponds with the detections as JSON.")
+		fmt.Fprintln(os.Stderr, "GET /health")
+		fmt.Fprintln(os.Stderr, "\tResponds with a description of the model as JSON.")
+		fmt.Fprintln(os.Stderr)
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	var (
+		addr = flag.String("addr", "localhost:8080", "Address on which to listen.")
+		// Server options.
+		maxConc   = flag.Int("max-concurrent", 4, "Maximum number of requests to process concurrently.")
+		maxBytes  = flag.Int64("max-bytes", 32<<20, "Maximum size of an uploaded image in bytes.")
+		maxPixels = flag.Int("max-pixels", 4096*4096, "Maximum number of pixels in an uploaded image.")
+		// Detection options.
+		pyrStep  = flag.Float64("pyr-step", 1.2, "Geometric scale steps in image pyramid.")
+		maxScale = flag.Float64("max-scale", 1, "Maximum amount to scale image. Greater than 1 is upsampling.")
+		margin   = flag.Int("margin", 0, "Margin to add around the image before computing features.")
+		workers  = flag.Int("workers", 1, "Number of pyramid levels to evaluate concurrently within a request.")
+		// Defaults which can be overridden per request.
+		maxIOU   = flag.Float64("max-iou", 0.3, "Maximum IOU that two detections can have after NMS.")
+		maxNum   = flag.Int("max-num", 0, "Maximum number of detections per image. Zero means no limit.")
+		minScore = flag.Float64("min-score", math.Inf(-1), "Minimum score of a detection before calibration.")
+	)
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	var (
+		tmplFile      = flag.Arg(0)
+		transformFile = flag.Arg(1)
+	)
+	if *maxConc < 1 {
+		log.Fatalln("max-concurrent must be positive")
+	}
+	if *maxPixels < 1 {
+		log.Fatalln("max-pixels must be positive")
+	}
+
+	var tmpl *detect.FeatTmpl
+	if err := fileutil.LoadExt(tmplFile, &tmpl); err != nil {
+		log.Fatalln("load template:", err)
+	}
+	var transform *featset.ImageMarshaler
+	if err := fileutil.LoadJSON(transformFile, &transform); err != nil {
+		log.Fatalln("load transform:", err)
+	}
+	if tmpl.Scorer.Tmpl.Channels != transform.Channels() {
+		log.Fatalf("template has %d channels, transform has %d", tmpl.Scorer.Tmpl.Channels, transform.Channels())
+	}
+
+	opts := detect.MultiScaleOpts{
+		MaxScale:  *maxScale,
+		PyrStep:   *pyrStep,
+		Interp:    resize.Bicubic,
+		Transform: transform,
+		Pad:       feat.Pad{feat.UniformMargin(*margin), imsamp.Continue},
+		Workers:   *workers,
+	}
+	defaults := Params{MaxIOU: *maxIOU, MaxNum: *maxNum, MinScore: *minScore}
+	srv := newServer(tmpl, transform, opts, defaults, *maxConc, *maxBytes, *maxPixels)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/detect", srv.handleDetect)
+	mux.HandleFunc("/health", srv.handleHealth)
+	log.Print("listen on ", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+// errTooLarge is wrapped by the errors of images
+// which exceed the limits of the server.
+var errTooLarge = errors.New("image is too large")
+
+// Decodes an image which has at most maxPixels pixels.
+// The dimensions are read from the header before the image is decoded.
+func decodeImage(r io.Reader, maxPixels int) (image.Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %v", err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > int64(maxPixels) {
+		return nil, fmt.Errorf("%w: %dx%d, max %d pixels", errTooLarge, cfg.Width, cfg.Height, maxPixels)
+	}
+	im, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %v", err)
+	}
+	return im, nil
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/featset"
+)
+
+// Params are the options which can be overridden per request.
+type Params struct {
+	// Maximum intersection-over-union between detections after NMS.
+	MaxIOU float64
+	// Maximum number of detections. Zero means no limit.
+	MaxNum int
+	// Minimum score of a detection before calibration.
+	MinScore float64
+}
+
+// Parses the query parameters of a request.
+// Parameters which are not specified take the default value.
+func parseParams(r *http.Request, defaults Params) (Params, error) {
+	p := defaults
+	q := r.URL.Query()
+	if s := q.Get("max-iou"); s != "" {
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil || !(x >= 0 && x <= 1) {
+			return Params{}, fmt.Errorf("max-iou must be in [0, 1]: %q", s)
+		}
+		p.MaxIOU = x
+	}
+	if s := q.Get("max-num"); s != "" {
+		x, err := strconv.Atoi(s)
+		if err != nil || x < 0 {
+			return Params{}, fmt.Errorf("max-num must be a non-negative integer: %q", s)
+		}
+		p.MaxNum = x
+	}
+	if s := q.Get("min-score"); s != "" {
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(x) {
+			return Params{}, fmt.Errorf("min-score must be a number: %q", s)
+		}
+		p.MinScore = x
+	}
+	return p, nil
+}
+
+// Applies the parameters to a copy of the options.
+func (p Params) apply(opts detect.MultiScaleOpts) detect.MultiScaleOpts {
+	maxIOU := p.MaxIOU
+	opts.DetFilter = detect.DetFilter{LocalMax: true, MinScore: p.MinScore}
+	opts.SupprFilter = detect.SupprFilter{
+		MaxNum:  p.MaxNum,
+		Overlap: func(a, b image.Rectangle) bool { return detect.IOU(a, b) > maxIOU },
+	}
+	return opts
+}
+
+// Infinite values cannot be represented in JSON.
+type paramsJSON struct {
+	MaxIOU   float64
+	MaxNum   int
+	MinScore *float64 `json:",omitempty"`
+}
+
+func (p Params) MarshalJSON() ([]byte, error) {
+	x := paramsJSON{MaxIOU: p.MaxIOU, MaxNum: p.MaxNum}
+	if !math.IsInf(p.MinScore, 0) {
+		x.MinScore = &p.MinScore
+	}
+	return json.Marshal(x)
+}
+
+type server struct {
+	tmpl      *detect.FeatTmpl
+	transform *featset.ImageMarshaler
+	opts      detect.MultiScaleOpts
+	defaults  Params
+	maxBytes  int64
+	maxPixels int
+	// Bounds the number of requests which are processed concurrently.
+	sem chan struct{}
+	// Number of requests which are processing or waiting.
+	active int64
+}
+
+func newServer(tmpl *detect.FeatTmpl, transform *featset.ImageMarshaler, opts detect.MultiScaleOpts, defaults Params, maxConc int, maxBytes int64, maxPixels int) *server {
+	return &server{
+		tmpl:      tmpl,
+		transform: transform,
+		opts:      opts,
+		defaults:  defaults,
+		maxBytes:  maxBytes,
+		maxPixels: maxPixels,
+		sem:       make(chan struct{}, maxConc),
+	}
+}
+
+// DetectResponse is the response to POST /detect.
+type DetectResponse struct {
+	// Size of the image.
+	Width, Height int
+	// Detections ordered (descending) by score.
+	Dets []detect.Det
+	// Parameters which were used.
+	// MinScore applies to the scores before calibration.
+	Params Params
+	// Whether the scores have been calibrated.
+	Calibrated bool
+	// Time taken to read the image and perform detection,
+	// excluding time spent waiting.
+	Seconds float64
+}
+
+func (s *server) handleDetect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	params, err := parseParams(r, s.defaults)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	atomic.AddInt64(&s.active, 1)
+	defer atomic.AddInt64(&s.active, -1)
+	// Wait for a slot before reading the image
+	// so that waiting requests do not hold decoded images.
+	ctx := r.Context()
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-s.sem }()
+
+	t := time.Now()
+	im, err := s.readImage(w, r)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, errTooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	dets, err := detect.MultiScaleContext(ctx, im, s.tmpl.Scorer, s.tmpl.PixelShape, params.apply(s.opts))
+	if err != nil {
+		if ctx.Err() != nil {
+			// Client has gone.
+			return
+		}
+		log.Print("detect: ", err)
+		http.Error(w, "detect: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if calib := s.tmpl.Calib; calib != nil {
+		// Calibration is non-decreasing and does not change the order.
+		for i := range dets {
+			dets[i].Score = calib.Calibrate(dets[i].Score)
+		}
+	}
+	if dets == nil {
+		dets = []detect.Det{}
+	}
+	size := im.Bounds().Size()
+	writeJSON(w, &DetectResponse{
+		Width:      size.X,
+		Height:     size.Y,
+		Dets:       dets,
+		Params:     params,
+		Calibrated: s.tmpl.Calib != nil,
+		Seconds:    time.Since(t).Seconds(),
+	})
+}
+
+// Reads the image from the multipart form field "image"
+// or otherwise from the request body.
+// The size of the image is checked before it is decoded.
+// The error wraps errTooLarge if the body or the image exceeds the limits.
+func (s *server) readImage(w http.ResponseWriter, r *http.Request) (image.Image, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBytes)
+	im, err := s.readBody(r)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return nil, fmt.Errorf("%w: more than %d bytes", errTooLarge, maxErr.Limit)
+	}
+	return im, err
+}
+
+func (s *server) readBody(r *http.Request) (image.Image, error) {
+	mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediatype != "multipart/form-data" {
+		return decodeImage(r.Body, s.maxPixels)
+	}
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		return nil, fmt.Errorf("form field image: %w", err)
+	}
+	defer file.Close()
+	return decodeImage(file, s.maxPixels)
+}
+
+// HealthResponse is the response to GET /health.
+type HealthResponse struct {
+	Status string
+	// Name of the feature transform.
+	Transform string
+	Rate      int
+	Channels  int
+	// Size of the template in feature pixels.
+	TmplSize image.Point
+	// Region of the template and the bounding box within it, in pixels.
+	PixelShape detect.PadRect
+	// Name of the calibration which is applied to the scores, if any.
+	Calib string `json:",omitempty"`
+	// Pyramid options.
+	PyrStep  float64
+	MaxScale float64
+	// Default parameters of a request.
+	Defaults Params
+	// Limit on concurrent requests
+	// and the number of requests which are processing or waiting.
+	MaxConcurrent int
+	Active        int64
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	resp := &HealthResponse{
+		Status:        "ok",
+		Transform:     s.transform.Name,
+		Rate:          s.transform.Rate(),
+		Channels:      s.transform.Channels(),
+		TmplSize:      s.tmpl.Scorer.Size(),
+		PixelShape:    s.tmpl.PixelShape,
+		PyrStep:       s.opts.PyrStep,
+		MaxScale:      s.opts.MaxScale,
+		Defaults:      s.defaults,
+		MaxConcurrent: cap(s.sem),
+		Active:        atomic.LoadInt64(&s.active),
+	}
+	if s.tmpl.Calib != nil {
+		resp.Calib = s.tmpl.Calib.Name
+	}
+	writeJSON(w, resp)
+}
+
+func writeJSON(w http.ResponseWriter, x interface{}) {
+	buf, err := json.Marshal(x)
+	if err != nil {
+		log.Print("encode response: ", err)
+		http.Error(w, "encode response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(buf, '\n'))
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"math"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/feat"
+	"github.com/jvlmdr/go-cv/featset"
+	"github.com/jvlmdr/go-cv/imsamp"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/slide"
+	"github.com/nfnt/resize"
+)
+
+func TestParseParams(t *testing.T) {
+	defaults := Params{MaxIOU: 0.3, MaxNum: 0, MinScore: math.Inf(-1)}
+	cases := []struct {
+		Query string
+		Want  Params
+		Err   bool
+	}{
+		{"", defaults, false},
+		{"max-iou=0.5", Params{0.5, 0, math.Inf(-1)}, false},
+		{"max-num=10&min-score=-1.5", Params{0.3, 10, -1.5}, false},
+		{"max-iou=1.5", Params{}, true},
+		{"max-iou=abc", Params{}, true},
+		{"max-num=-1", Params{}, true},
+		{"max-num=2.5", Params{}, true},
+		{"min-score=NaN", Params{}, true},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/detect?"+c.Query, nil)
+		got, err := parseParams(r, defaults)
+		if c.Err {
+			if err == nil {
+				t.Errorf("%q: expected error", c.Query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", c.Query, err)
+			continue
+		}
+		if !reflect.DeepEqual(c.Want, got) {
+			t.Errorf("%q: want %+v, got %+v", c.Query, c.Want, got)
+		}
+	}
+}
+
+func TestParams_MarshalJSON(t *testing.T) {
+	buf, err := json.Marshal(Params{MaxIOU: 0.3, MaxNum: 5, MinScore: math.Inf(-1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "MinScore") {
+		t.Errorf("infinite MinScore should be omitted: %s", buf)
+	}
+	buf, err = json.Marshal(Params{MaxIOU: 0.3, MaxNum: 5, MinScore: -2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Params
+	if err := json.Unmarshal(buf, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Params{0.3, 5, -2}); got != want {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestHandleDetect(t *testing.T) {
+	s := newTestServer(1 << 20)
+	body := encodePNG(t, noiseImage(48, 40, rand.New(rand.NewSource(2))))
+	r := httptest.NewRequest(http.MethodPost, "/detect?max-num=3", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	s.handleDetect(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var resp DetectResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Width != 48 || resp.Height != 40 {
+		t.Errorf("want size 48x40, got %dx%d", resp.Width, resp.Height)
+	}
+	if len(resp.Dets) == 0 || len(resp.Dets) > 3 {
+		t.Errorf("want between 1 and 3 detections, got %d", len(resp.Dets))
+	}
+	for i := 1; i < len(resp.Dets); i++ {
+		if resp.Dets[i].Score > resp.Dets[i-1].Score {
+			t.Errorf("detections not in descending order of score")
+		}
+	}
+	if resp.Params.MaxNum != 3 || resp.Params.MaxIOU != 0.3 {
+		t.Errorf("wrong parameters: %+v", resp.Params)
+	}
+	if resp.Calibrated {
+		t.Errorf("template has no calibration")
+	}
+}
+
+func TestHandleDetect_method(t *testing.T) {
+	s := newTestServer(1 << 20)
+	w := httptest.NewRecorder()
+	s.handleDetect(w, httptest.NewRequest(http.MethodGet, "/detect", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("want status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != http.MethodPost {
+		t.Errorf("want Allow %q, got %q", http.MethodPost, allow)
+	}
+}
+
+func TestHandleDetect_badRequest(t *testing.T) {
+	s := newTestServer(32 * 32)
+	small := encodePNG(t, noiseImage(32, 32, rand.New(rand.NewSource(1))))
+	cases := []struct {
+		Name  string
+		Query string
+		Body  []byte
+	}{
+		{"params", "?max-iou=2", small},
+		{"not an image", "", []byte("hello")},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/detect"+c.Query, bytes.NewReader(c.Body))
+		w := httptest.NewRecorder()
+		s.handleDetect(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: want status %d, got %d", c.Name, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestHandleDetect_tooLarge(t *testing.T) {
+	s := newTestServer(32 * 32)
+	large := encodePNG(t, noiseImage(64, 32, rand.New(rand.NewSource(1))))
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, err := mw.CreateFormFile("image", "large.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(large)
+	mw.Close()
+
+	cases := []struct {
+		Name     string
+		MaxBytes int64
+		Type     string
+		Body     []byte
+	}{
+		{"pixels", 1 << 20, "image/png", large},
+		{"bytes", 100, "image/png", large},
+		{"form pixels", 1 << 20, mw.FormDataContentType(), form.Bytes()},
+		{"form bytes", 100, mw.FormDataContentType(), form.Bytes()},
+	}
+	for _, c := range cases {
+		s.maxBytes = c.MaxBytes
+		r := httptest.NewRequest(http.MethodPost, "/detect", bytes.NewReader(c.Body))
+		r.Header.Set("Content-Type", c.Type)
+		w := httptest.NewRecorder()
+		s.handleDetect(w, r)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: want status %d, got %d: %s", c.Name, http.StatusRequestEntityTooLarge, w.Code, w.Body)
+		}
+	}
+}
+
+// Creates a server with a random template and the gray transform.
+func newTestServer(maxPixels int) *server {
+	r := rand.New(rand.NewSource(1))
+	tmpl := rimg64.NewMulti(8, 8, 1)
+	for i := range tmpl.Elems {
+		tmpl.Elems[i] = r.NormFloat64()
+	}
+	model := &detect.FeatTmpl{
+		Scorer:     &slide.AffineScorer{Tmpl: tmpl},
+		PixelShape: detect.PadRect{Size: image.Pt(8, 8), Int: image.Rect(0, 0, 8, 8)},
+	}
+	transform := &featset.ImageMarshaler{"gray", new(featset.Gray)}
+	opts := detect.MultiScaleOpts{
+		MaxScale:  1,
+		PyrStep:   1.2,
+		Interp:    resize.Bilinear,
+		Transform: transform,
+		Pad:       feat.Pad{feat.UniformMargin(0), imsamp.Continue},
+	}
+	defaults := Params{MaxIOU: 0.3, MinScore: math.Inf(-1)}
+	return newServer(model, transform, opts, defaults, 1, 1<<20, maxPixels)
+}
+
+func noiseImage(width, height int, r *rand.Rand) image.Image {
+	im := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range im.Pix {
+		im.Pix[i] = uint8(r.Intn(256))
+	}
+	return im
+}
+
+func encodePNG(t *testing.T, im image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, im); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
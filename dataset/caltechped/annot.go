@@ -35,19 +35,11 @@ func (obj Object) VisFrac() float64 {
 // ObjectFilter decides whether to use a ground truth annotation.
 type ObjectFilter func(obj Object) bool
 
+// Reasonable returns whether an object is a reference
+// under the "reasonable" protocol.
+// Satisfies ObjectFilter.
 func Reasonable(obj Object) bool {
-	if obj.Label != "person" {
-		return false
-	}
-	// Minimum height 50 pixels.
-	if obj.Rect.Dy() < 50 {
-		return false
-	}
-	// At least 65% visible.
-	if obj.VisFrac() < 0.65 {
-		return false
-	}
-	return true
+	return ReasonableFilter().Apply(obj) == Keep
 }
 
 // LoadAnnot loads the annotation of an image.
@@ -90,10 +82,10 @@ func firstRune(s string) rune {
 }
 
 // KeepLabel determines whether to include a box in the image annotation.
+// Boxes labelled "person-fa" are kept because ReasonableFilter ignores them.
 func KeepLabel(label string) bool {
-	// TODO: Sure not to include "person-fa" in this set?
 	switch label {
-	case "person", "people", "person?", "ignore":
+	case "person", "people", "person?", "person-fa", "ignore":
 		return true
 	default:
 		return false
@@ -0,0 +1,82 @@
+package caltechped
+
+import (
+	"image"
+	"math"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+// EvalOpts specifies the evaluation protocol.
+type EvalOpts struct {
+	// Decides which objects are references and which are ignored.
+	Filter *AnnotFilter
+	// The width of every reference, ignore region and detection
+	// is changed to achieve this aspect ratio (width / height)
+	// without changing its height or center.
+	// If zero, the rectangles are not modified.
+	Aspect float64
+	// Minimum intersection-over-union to match a reference.
+	MinIOU float64
+	// Minimum fraction of a detection which must be covered
+	// by an ignore region to be ignored.
+	// An ignore region can match any number of detections.
+	MinIgnoreCover float64
+	// Strategy for assigning detections to references.
+	Match detect.MatchStrategy
+}
+
+// DefaultEvalOpts returns the options of the standard evaluation
+// under the "reasonable" protocol.
+func DefaultEvalOpts() EvalOpts {
+	return EvalOpts{
+		Filter:         ReasonableFilter(),
+		Aspect:         0.41,
+		MinIOU:         0.5,
+		MinIgnoreCover: 0.5,
+	}
+}
+
+// Validate compares the detections in an image to its annotation.
+// The detections must be ordered (descending) by score.
+// The returned detections have the standardized aspect ratio.
+func Validate(dets []detect.Det, annot ImageAnnot, opts EvalOpts) *detect.ValImage {
+	refs, ignore := Rects(annot, opts.Filter)
+	if opts.Aspect > 0 {
+		refs = setAspectAll(refs, opts.Aspect)
+		ignore = setAspectAll(ignore, opts.Aspect)
+		std := make([]detect.Det, len(dets))
+		for i, det := range dets {
+			std[i] = detect.Det{det.Score, setAspect(det.Rect, opts.Aspect)}
+		}
+		dets = std
+	}
+	return detect.ValidateMatch(dets, refs, ignore, opts.MinIOU, opts.MinIgnoreCover, opts.Match)
+}
+
+// LogAvgMissRate computes the log-average miss rate
+// over the nine rates of false positives per image
+// which are evenly spaced in log-space in [1e-2, 1e0].
+func LogAvgMissRate(valset *detect.ValSet) float64 {
+	return detect.LogAvgMissRate(valset, detect.CaltechFPPIs())
+}
+
+func setAspectAll(rects []image.Rectangle, aspect float64) []image.Rectangle {
+	std := make([]image.Rectangle, len(rects))
+	for i, r := range rects {
+		std[i] = setAspect(r, aspect)
+	}
+	return std
+}
+
+// Changes the width of a rectangle to achieve the aspect ratio.
+func setAspect(r image.Rectangle, aspect float64) image.Rectangle {
+	w, h := detect.SetAspect(float64(r.Dx()), float64(r.Dy()), aspect, "height")
+	x := float64(r.Min.X+r.Max.X) / 2
+	y := float64(r.Min.Y+r.Max.Y) / 2
+	return image.Rect(round(x-w/2), round(y-h/2), round(x+w/2), round(y+h/2))
+}
+
+func round(x float64) int {
+	return int(math.Floor(x + 0.5))
+}
@@ -0,0 +1,100 @@
+package caltechped_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/dataset/caltechped"
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+func TestReasonableFilter(t *testing.T) {
+	full := image.Rect(0, 0, 40, 100)
+	cases := []struct {
+		Name string
+		Obj  caltechped.Object
+		Want caltechped.Decision
+	}{
+		{"person", caltechped.Object{Label: "person", Rect: full}, caltechped.Keep},
+		{"short", caltechped.Object{Label: "person", Rect: image.Rect(0, 0, 20, 49)}, caltechped.Ignore},
+		{"min height", caltechped.Object{Label: "person", Rect: image.Rect(0, 0, 20, 50)}, caltechped.Keep},
+		{"occluded", caltechped.Object{Label: "person", Rect: full, Occl: true, Vis: image.Rect(0, 0, 40, 50)}, caltechped.Ignore},
+		{"visible", caltechped.Object{Label: "person", Rect: full, Occl: true, Vis: image.Rect(0, 0, 40, 70)}, caltechped.Keep},
+		{"people", caltechped.Object{Label: "people", Rect: full}, caltechped.Ignore},
+		{"person?", caltechped.Object{Label: "person?", Rect: full}, caltechped.Ignore},
+		{"person-fa", caltechped.Object{Label: "person-fa", Rect: full}, caltechped.Ignore},
+		{"other", caltechped.Object{Label: "ignore", Rect: full}, caltechped.Discard},
+	}
+	filt := caltechped.ReasonableFilter()
+	for _, c := range cases {
+		if got := filt.Apply(c.Obj); got != c.Want {
+			t.Errorf("%s: want %v, got %v", c.Name, c.Want, got)
+		}
+	}
+}
+
+func TestValidate_aspect(t *testing.T) {
+	annot := caltechped.ImageAnnot{Objects: []caltechped.Object{
+		{Label: "person", Rect: image.Rect(0, 0, 20, 100)},
+		{Label: "people", Rect: image.Rect(200, 0, 210, 100)},
+	}}
+	dets := []detect.Det{
+		// IOU with the reference is 20/41 before the aspect is changed
+		// and one after.
+		{2, image.Rect(-10, 0, 31, 100)},
+		// Covered by 10/40 of the ignore region before and 40/41 after.
+		{1, image.Rect(186, 0, 226, 100)},
+	}
+
+	opts := caltechped.DefaultEvalOpts()
+	opts.Aspect = 0
+	val := caltechped.Validate(dets, annot, opts)
+	if val.Dets[0].True || val.Dets[1].Ignore || len(val.Misses) != 1 {
+		t.Errorf("original aspect: want false, false and one miss, got %+v, %+v and %d misses",
+			val.Dets[0].Val, val.Dets[1].Val, len(val.Misses))
+	}
+
+	opts.Aspect = 0.41
+	val = caltechped.Validate(dets, annot, opts)
+	want := []image.Rectangle{image.Rect(-10, 0, 31, 100), image.Rect(186, 0, 227, 100)}
+	for i, det := range val.Dets {
+		if det.Rect != want[i] {
+			t.Errorf("detection %d: want %v, got %v", i, want[i], det.Rect)
+		}
+	}
+	if !val.Dets[0].True || val.Dets[0].Ref != image.Rect(-10, 0, 31, 100) {
+		t.Errorf("first detection: want match to standardized reference, got %+v", val.Dets[0].Val)
+	}
+	if !val.Dets[1].Ignore {
+		t.Errorf("second detection: want ignored, got %+v", val.Dets[1].Val)
+	}
+	if len(val.Misses) != 0 {
+		t.Errorf("want no misses, got %v", val.Misses)
+	}
+}
+
+func TestValidate_ignoreMany(t *testing.T) {
+	annot := caltechped.ImageAnnot{Objects: []caltechped.Object{
+		{Label: "person", Rect: image.Rect(0, 0, 41, 100)},
+		{Label: "people", Rect: image.Rect(280, 0, 321, 100)},
+	}}
+	// Detections of half the height within the ignore region.
+	dets := []detect.Det{
+		{4, image.Rect(280, 0, 300, 50)},
+		{3, image.Rect(1, 0, 42, 100)},
+		{2, image.Rect(300, 50, 321, 100)},
+		{1, image.Rect(290, 20, 310, 70)},
+	}
+	val := caltechped.Validate(dets, annot, caltechped.DefaultEvalOpts())
+	for _, i := range []int{0, 2, 3} {
+		if !val.Dets[i].Ignore {
+			t.Errorf("detection %d: want ignored, got %+v", i, val.Dets[i].Val)
+		}
+	}
+	if !val.Dets[1].True {
+		t.Errorf("detection 1: want true, got %+v", val.Dets[1].Val)
+	}
+	if len(val.Misses) != 0 {
+		t.Errorf("want no misses, got %v", val.Misses)
+	}
+}
@@ -0,0 +1,108 @@
+package caltechped
+
+import (
+	"image"
+	"math"
+)
+
+// AnnotFilter decides which objects are references,
+// which are ignored and which are discarded.
+type AnnotFilter struct {
+	// Labels of the positive class.
+	Labels StrSet
+	// Labels of objects which are ignored.
+	Ignore StrSet
+	// Objects in the positive class are ignored
+	// if their height, visible fraction or aspect ratio
+	// is outside these intervals.
+	Height  Interval
+	Visible Interval
+	Aspect  Interval
+}
+
+// ReasonableFilter returns the filter of the "reasonable" protocol:
+// pedestrians at least 50 pixels tall and at least 65% visible.
+func ReasonableFilter() *AnnotFilter {
+	return &AnnotFilter{
+		Labels:  []string{"person"},
+		Ignore:  []string{"people", "person?", "person-fa"},
+		Height:  Interval{Min: 50, Max: math.Inf(1)},
+		Visible: Interval{Min: 0.65, Max: 1},
+		Aspect:  Interval{Min: 0, Max: math.Inf(1)},
+	}
+}
+
+type StrSet []string
+
+func (set StrSet) Contains(x string) bool {
+	for _, s := range set {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Defines an interval [Min, Max] or its complement.
+type Interval struct {
+	Min float64
+	Max float64
+	// Take the complement of the interval.
+	Inv bool
+}
+
+func (r Interval) Contains(x float64) bool {
+	in := r.Min <= x && x <= r.Max
+	if r.Inv {
+		in = !in
+	}
+	return in
+}
+
+// Decision is the outcome of applying a filter to an object.
+type Decision int
+
+const (
+	Keep Decision = iota
+	Discard
+	Ignore
+)
+
+// Apply decides whether an object is a reference, ignored or discarded.
+func (filt *AnnotFilter) Apply(obj Object) Decision {
+	if !filt.Labels.Contains(obj.Label) {
+		// Object is not in positive class.
+		if filt.Ignore.Contains(obj.Label) {
+			// Object is in ignored class.
+			return Ignore
+		}
+		return Discard
+	}
+	// Object is in positive class.
+	if !filt.Visible.Contains(obj.VisFrac()) {
+		// Object has visibility outside range.
+		return Ignore
+	}
+	if !filt.Height.Contains(float64(obj.Rect.Dy())) {
+		// Object has height outside range.
+		return Ignore
+	}
+	if !filt.Aspect.Contains(aspect(obj.Rect)) {
+		// Object has aspect ratio outside range.
+		return Ignore
+	}
+	return Keep
+}
+
+// Rects returns the reference and ignore rectangles.
+func Rects(annot ImageAnnot, filt *AnnotFilter) (refs, ignore []image.Rectangle) {
+	for _, obj := range annot.Objects {
+		switch filt.Apply(obj) {
+		case Keep:
+			refs = append(refs, obj.Rect)
+		case Ignore:
+			ignore = append(ignore, obj.Rect)
+		}
+	}
+	return refs, ignore
+}
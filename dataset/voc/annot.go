@@ -5,6 +5,8 @@ import (
 	"image"
 	"os"
 	"path"
+
+	"github.com/jvlmdr/go-cv/detect"
 )
 
 // Annot describes the annotation of an entire image.
@@ -78,3 +80,17 @@ func LoadAnnot(dir, im string) (Annot, error) {
 func imageFile(im string) string {
 	return path.Join("JPEGImages", im+".jpg")
 }
+
+// Rects returns the labelled regions of the objects in an image.
+// Difficult objects are returned as ignore regions.
+func (a Annot) Rects() (refs, ignore []detect.LabelRect) {
+	for _, obj := range a.Objects {
+		r := detect.LabelRect{obj.Class, obj.Region}
+		if obj.Difficult {
+			ignore = append(ignore, r)
+		} else {
+			refs = append(refs, r)
+		}
+	}
+	return refs, ignore
+}
@@ -0,0 +1,83 @@
+package detect
+
+import "math"
+
+// PrecRecall computes the precision and recall
+// at each operating point achieved by varying the threshold.
+// Element i is the operating point at which Dets[0, ..., i] are positive.
+// Recall is NaN if there are no positive instances.
+func PrecRecall(valset *ValSet) (prec, rec []float64) {
+	numPos := numTrue(valset.Dets) + valset.Misses
+	prec = make([]float64, len(valset.Dets))
+	rec = make([]float64, len(valset.Dets))
+	var truePos int
+	for i, det := range valset.Dets {
+		if det.True {
+			truePos++
+		}
+		prec[i] = float64(truePos) / float64(i+1)
+		rec[i] = float64(truePos) / float64(numPos)
+	}
+	return prec, rec
+}
+
+// AvgPrec computes the average precision as in PASCAL VOC 2010 and later.
+// This is the area under the precision-recall curve
+// after precision has been made monotonic by taking
+// the maximum precision at any greater recall.
+// Returns NaN if there are no positive instances.
+func AvgPrec(valset *ValSet) float64 {
+	if numTrue(valset.Dets)+valset.Misses == 0 {
+		return math.NaN()
+	}
+	prec, rec := PrecRecall(valset)
+	interp := interpPrec(prec)
+	// Sum rectangles where recall changes.
+	var (
+		ap   float64
+		prev float64
+	)
+	for i := range rec {
+		if rec[i] == prev {
+			continue
+		}
+		ap += (rec[i] - prev) * interp[i]
+		prev = rec[i]
+	}
+	return ap
+}
+
+// AvgPrec11 computes the 11-point average precision as in PASCAL VOC 2007.
+// This is the mean of the maximum precision at recall of at least
+// 0, 0.1, ..., 1.
+// Returns NaN if there are no positive instances.
+func AvgPrec11(valset *ValSet) float64 {
+	if numTrue(valset.Dets)+valset.Misses == 0 {
+		return math.NaN()
+	}
+	prec, rec := PrecRecall(valset)
+	var ap float64
+	for i := 0; i <= 10; i++ {
+		t := float64(i) / 10
+		var max float64
+		for j := range prec {
+			if rec[j] >= t {
+				max = math.Max(max, prec[j])
+			}
+		}
+		ap += max / 11
+	}
+	return ap
+}
+
+// Makes precision monotonic by taking the maximum precision
+// at the same or greater recall.
+func interpPrec(prec []float64) []float64 {
+	interp := make([]float64, len(prec))
+	var max float64
+	for i := len(prec) - 1; i >= 0; i-- {
+		max = math.Max(max, prec[i])
+		interp[i] = max
+	}
+	return interp
+}
@@ -0,0 +1,81 @@
+package detect_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+func TestAvgPrec(t *testing.T) {
+	valset := &detect.ValSet{
+		Dets: []detect.ValScore{
+			{4, true},
+			{3, false},
+			{2, true},
+			{1, false},
+		},
+		Misses: 2,
+		Images: 1,
+	}
+	prec, rec := detect.PrecRecall(valset)
+	wantPrec := []float64{1, 1.0 / 2, 2.0 / 3, 2.0 / 4}
+	wantRec := []float64{1.0 / 4, 1.0 / 4, 2.0 / 4, 2.0 / 4}
+	for i := range wantPrec {
+		if math.Abs(prec[i]-wantPrec[i]) > 1e-9 || math.Abs(rec[i]-wantRec[i]) > 1e-9 {
+			t.Errorf("det %d: want (%.3g, %.3g), got (%.3g, %.3g)", i, wantPrec[i], wantRec[i], prec[i], rec[i])
+		}
+	}
+
+	// Interpolated precision is 1, 2/3, 2/3, 1/2.
+	if got, want := detect.AvgPrec(valset), 1.0/4+2.0/3/4; math.Abs(got-want) > 1e-9 {
+		t.Errorf("all-points: want %.6g, got %.6g", want, got)
+	}
+	// Recall 0, 0.1, 0.2 achieve 1, recall 0.3, 0.4, 0.5 achieve 2/3.
+	if got, want := detect.AvgPrec11(valset), (3+3*2.0/3)/11; math.Abs(got-want) > 1e-9 {
+		t.Errorf("11-point: want %.6g, got %.6g", want, got)
+	}
+
+	if ap := detect.AvgPrec(new(detect.ValSet)); !math.IsNaN(ap) {
+		t.Errorf("no positives: want NaN, got %g", ap)
+	}
+}
+
+func TestAvgPrec_perfect(t *testing.T) {
+	valset := &detect.ValSet{Dets: []detect.ValScore{{2, true}, {1, true}, {0, false}}, Images: 1}
+	if ap := detect.AvgPrec(valset); ap != 1 {
+		t.Errorf("all-points: want 1, got %g", ap)
+	}
+	if ap := detect.AvgPrec11(valset); math.Abs(ap-1) > 1e-9 {
+		t.Errorf("11-point: want 1, got %g", ap)
+	}
+}
+
+func TestValidate_ignore(t *testing.T) {
+	dets := []detect.Det{
+		{10, image.Rect(0, 0, 100, 100)},
+		// Inside the difficult region.
+		{9, image.Rect(210, 10, 290, 90)},
+		{8, image.Rect(400, 0, 500, 100)},
+	}
+	refs := []image.Rectangle{image.Rect(5, 0, 105, 100)}
+	ignore := []image.Rectangle{image.Rect(200, 0, 300, 100)}
+	val := detect.Validate(dets, refs, ignore, 0.5, 0.5)
+	if !val.Dets[1].Ignore {
+		t.Fatal("detection in ignore region was not ignored")
+	}
+	if len(val.Misses) != 0 {
+		t.Errorf("ignore region counted as miss: %v", val.Misses)
+	}
+	scores := val.Scores()
+	want := []detect.ValScore{{10, true}, {8, false}}
+	if len(scores) != len(want) {
+		t.Fatalf("wrong number of scores: want %d, got %d", len(want), len(scores))
+	}
+	for i := range want {
+		if scores[i] != want[i] {
+			t.Errorf("score %d: want %v, got %v", i, want[i], scores[i])
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package batch performs detection on a sequence of images
+// using a pool of workers.
+//
+// Results are delivered in the order of the input
+// and can be written to a file with one JSON object per line.
+// A partially written file can be used to resume a run.
+package batch
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/metrics"
+)
+
+// Item is an image to be processed.
+type Item struct {
+	// Unique identifier of the image.
+	ID string
+	// Loads the image. Called by the worker which processes the item.
+	Load func() (image.Image, error)
+}
+
+// Source provides images in order.
+type Source interface {
+	// Next returns the next item.
+	// Returns io.EOF when there are no more items.
+	Next() (Item, error)
+}
+
+// Detector performs detection in an image.
+// It must be safe for concurrent use.
+type Detector interface {
+	// Detect finds the objects in an image
+	// and reports measurements to rec.
+	Detect(im image.Image, rec metrics.Recorder) ([]detect.Det, error)
+}
+
+// MultiScale is a Detector which calls detect.MultiScale with a template.
+type MultiScale struct {
+	Tmpl *detect.FeatTmpl
+	Opts detect.MultiScaleOpts
+}
+
+func (m *MultiScale) Detect(im image.Image, rec metrics.Recorder) ([]detect.Det, error) {
+	opts := m.Opts
+	opts.Metrics = rec
+	return detect.MultiScale(im, m.Tmpl.Scorer, m.Tmpl.PixelShape, opts)
+}
+
+// Result describes the detections in one image.
+type Result struct {
+	ID   string
+	Dets []detect.Det
+	// Measurements of loading the image and each stage of detection.
+	Events []metrics.Event
+}
+
+// Opts specifies parameters to Run.
+type Opts struct {
+	// Number of images to process concurrently.
+	// If less than one, images are processed one at a time.
+	Workers int
+	// Items whose identifier is in Skip are neither processed nor emitted.
+	// See Resume.
+	Skip map[string]bool
+}
+
+// Run processes every item from the source and calls emit
+// with the result of each in the order of the source.
+//
+// Up to Workers items are processed concurrently
+// and at most Workers further results are held while waiting
+// for an earlier item to finish.
+// Run stops at the first error from the source, the detector or emit
+// and returns it after all workers have stopped.
+// The results of the items before the error have been emitted.
+func Run(src Source, det Detector, opts Opts, emit func(*Result) error) error {
+	n := opts.Workers
+	if n < 1 {
+		n = 1
+	}
+	var (
+		jobs = make(chan job)
+		// Pending results in order of the source.
+		order = make(chan chan outcome, n)
+		quit  = make(chan struct{})
+		wg    sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(order)
+		defer close(jobs)
+		for {
+			item, err := src.Next()
+			if err == io.EOF {
+				return
+			}
+			ch := make(chan outcome, 1)
+			if err != nil {
+				ch <- outcome{Err: err}
+			}
+			select {
+			case order <- ch:
+			case <-quit:
+				return
+			}
+			if err != nil {
+				return
+			}
+			if opts.Skip[item.ID] {
+				ch <- outcome{Skip: true}
+				continue
+			}
+			select {
+			case jobs <- job{item, ch}:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res, err := process(j.Item, det)
+				j.Out <- outcome{Result: res, Err: err}
+			}
+		}()
+	}
+
+	var err error
+	for ch := range order {
+		out := <-ch
+		if out.Skip {
+			continue
+		}
+		if out.Err != nil {
+			err = out.Err
+			break
+		}
+		if err = emit(out.Result); err != nil {
+			break
+		}
+	}
+	close(quit)
+	wg.Wait()
+	return err
+}
+
+type job struct {
+	Item Item
+	Out  chan<- outcome
+}
+
+type outcome struct {
+	Result *Result
+	Err    error
+	Skip   bool
+}
+
+func process(item Item, det Detector) (*Result, error) {
+	var c metrics.Collector
+	rec := metrics.Tag(&c, item.ID)
+	t := time.Now()
+	im, err := item.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %v", item.ID, err)
+	}
+	rec.Record(metrics.Event{Stage: metrics.Load, Level: -1, Dur: time.Since(t), Size: im.Bounds().Size()})
+	dets, err := det.Detect(im, rec)
+	if err != nil {
+		return nil, fmt.Errorf("detect %s: %v", item.ID, err)
+	}
+	return &Result{ID: item.ID, Dets: dets, Events: c.Events()}, nil
+}
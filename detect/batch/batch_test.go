@@ -0,0 +1,146 @@
+package batch_test
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/detect/batch"
+	"github.com/jvlmdr/go-cv/metrics"
+)
+
+// Returns one detection whose score is the width of the image
+// after a random delay.
+type fakeDetector struct{}
+
+func (fakeDetector) Detect(im image.Image, rec metrics.Recorder) ([]detect.Det, error) {
+	w := im.Bounds().Dx()
+	time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+	return []detect.Det{{float64(w), image.Rect(0, 0, w, 1)}}, nil
+}
+
+// Creates items whose image width is their index.
+// The image of item bad cannot be loaded.
+func items(n, bad int) *batch.List {
+	var list batch.List
+	for i := 0; i < n; i++ {
+		i := i
+		list.Items = append(list.Items, batch.Item{
+			ID: strconv.Itoa(i),
+			Load: func() (image.Image, error) {
+				if i == bad {
+					return nil, errors.New("bad image")
+				}
+				return image.NewGray(image.Rect(0, 0, i, 1)), nil
+			},
+		})
+	}
+	return &list
+}
+
+func TestRun(t *testing.T) {
+	const n = 50
+	var ids []string
+	err := batch.Run(items(n, -1), fakeDetector{}, batch.Opts{Workers: 4}, func(res *batch.Result) error {
+		if want := float64(len(ids)); res.Dets[0].Score != want {
+			t.Errorf("result %d: wrong detections: want score %g, got %g", len(ids), want, res.Dets[0].Score)
+		}
+		if len(res.Events) != 1 || res.Events[0].Stage != metrics.Load || res.Events[0].Image != res.ID {
+			t.Errorf("result %d: want one load event, got %+v", len(ids), res.Events)
+		}
+		ids = append(ids, res.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != n {
+		t.Fatalf("want %d results, got %d", n, len(ids))
+	}
+	for i, id := range ids {
+		if want := strconv.Itoa(i); id != want {
+			t.Errorf("result %d: want id %s, got %s", i, want, id)
+		}
+	}
+}
+
+func TestRun_error(t *testing.T) {
+	const (
+		n   = 50
+		bad = 20
+	)
+	var num int
+	err := batch.Run(items(n, bad), fakeDetector{}, batch.Opts{Workers: 4}, func(res *batch.Result) error {
+		num++
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if num != bad {
+		t.Errorf("want %d results before error, got %d", bad, num)
+	}
+}
+
+func TestResume(t *testing.T) {
+	const n = 20
+	fname := filepath.Join(t.TempDir(), "results.jsonl")
+	// Write some results and part of another.
+	file, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := batch.NewWriter(file)
+	var written int
+	err = batch.Run(items(n, -1), fakeDetector{}, batch.Opts{Workers: 3}, func(res *batch.Result) error {
+		if written == 7 {
+			fmt.Fprint(file, `{"ID":"7","Dets":[{"Sc`)
+			return errors.New("interrupted")
+		}
+		written++
+		return w.Write(res)
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	file.Close()
+
+	file, done, err := batch.Resume(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != written {
+		t.Errorf("want %d complete results, got %d", written, len(done))
+	}
+	w = batch.NewWriter(file)
+	err = batch.Run(items(n, -1), fakeDetector{}, batch.Opts{Workers: 3, Skip: done}, w.Write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	file, err = os.Open(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	results, _, err := batch.ReadResults(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != n {
+		t.Fatalf("want %d results, got %d", n, len(results))
+	}
+	for i, res := range results {
+		if want := strconv.Itoa(i); res.ID != want {
+			t.Errorf("result %d: want id %s, got %s", i, want, res.ID)
+		}
+	}
+}
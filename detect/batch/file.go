@@ -0,0 +1,85 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// Writer writes results with one JSON object per line.
+type Writer struct {
+	w io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w}
+}
+
+// Write writes one result.
+// The line is written in a single call to the underlying writer.
+func (w *Writer) Write(res *Result) error {
+	buf, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(buf, '\n'))
+	return err
+}
+
+// ReadResults reads the results which were written by a Writer.
+// If the last line is incomplete, it is discarded
+// and n gives the number of bytes which precede it.
+func ReadResults(r io.Reader) (results []*Result, n int64, err error) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// Final line was not terminated.
+			return results, n, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			res := new(Result)
+			if err := json.Unmarshal(line, res); err != nil {
+				return nil, 0, err
+			}
+			results = append(results, res)
+		}
+		n += int64(len(line))
+	}
+}
+
+// Resume opens a results file to continue a run.
+// The file is created if it does not exist.
+// Any incomplete line at the end of the file is removed.
+// Returns the file, positioned at the end, and the identifiers
+// of the items which have already been processed,
+// which can be given as Opts.Skip.
+func Resume(fname string) (*os.File, map[string]bool, error) {
+	file, err := os.OpenFile(fname, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, n, err := ReadResults(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if err := file.Truncate(n); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if _, err := file.Seek(n, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	done := make(map[string]bool)
+	for _, res := range results {
+		done[res.ID] = true
+	}
+	return file, done, nil
+}
@@ -0,0 +1,48 @@
+package batch
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+// List is a Source which provides a fixed list of items.
+type List struct {
+	Items []Item
+	next  int
+}
+
+func (l *List) Next() (Item, error) {
+	if l.next >= len(l.Items) {
+		return Item{}, io.EOF
+	}
+	item := l.Items[l.next]
+	l.next++
+	return item, nil
+}
+
+// Files returns a source which decodes each image file.
+// The identifier of each item is its file name.
+// The decoders for the image formats must be registered.
+func Files(names []string) *List {
+	items := make([]Item, len(names))
+	for i, name := range names {
+		name := name
+		items[i] = Item{name, func() (image.Image, error) { return loadImage(name) }}
+	}
+	return &List{Items: items}
+}
+
+func loadImage(fname string) (image.Image, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	im, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %v", err)
+	}
+	return im, nil
+}
@@ -0,0 +1,272 @@
+package detect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Calibrator maps a raw score to an estimate of the probability
+// that a detection is a true positive.
+// The mapping is non-decreasing.
+type Calibrator interface {
+	Calibrate(score float64) float64
+}
+
+// Calibrators maps the name of each calibration method
+// to a function which creates an empty instance.
+var Calibrators = map[string]func() Calibrator{
+	"platt":    func() Calibrator { return new(Platt) },
+	"isotonic": func() Calibrator { return new(Isotonic) },
+}
+
+// CalibMarshaler uses the default JSON marshaler and defines its own unmarshaler.
+type CalibMarshaler struct {
+	Name string
+	Spec Calibrator `json:",omitempty"`
+}
+
+func (m *CalibMarshaler) Calibrate(score float64) float64 { return m.Spec.Calibrate(score) }
+
+func (m *CalibMarshaler) UnmarshalJSON(data []byte) error {
+	var x struct {
+		Name string
+		Spec json.RawMessage
+	}
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	if len(x.Name) == 0 {
+		return fmt.Errorf("no calibration name specified")
+	}
+	create, ok := Calibrators[x.Name]
+	if !ok {
+		return fmt.Errorf(`unknown calibration: "%s"`, x.Name)
+	}
+	c := create()
+	if len(x.Spec) > 0 {
+		if err := json.Unmarshal(x.Spec, c); err != nil {
+			return err
+		}
+	}
+	m.Name = x.Name
+	m.Spec = c
+	return nil
+}
+
+// FitCalib fits a calibration to the detections in a validated set
+// using the named method ("platt" or "isotonic").
+// Missed instances do not have a score and are not used.
+func FitCalib(set *ValSet, method string) (*CalibMarshaler, error) {
+	var (
+		c   Calibrator
+		err error
+	)
+	switch method {
+	case "platt":
+		c, err = FitPlatt(set)
+	case "isotonic":
+		c, err = FitIsotonic(set)
+	default:
+		return nil, fmt.Errorf(`unknown calibration: "%s"`, method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &CalibMarshaler{method, c}, nil
+}
+
+// Platt maps a score to a probability using a sigmoid
+// 	p = 1 / (1 + exp(A s + B)).
+// A is negative for a non-decreasing mapping.
+type Platt struct {
+	A, B float64
+}
+
+func (c *Platt) Calibrate(score float64) float64 {
+	return sigmoid(-(c.A*score + c.B))
+}
+
+// Numerically stable evaluation of 1 / (1 + exp(-x)).
+func sigmoid(x float64) float64 {
+	if x >= 0 {
+		return 1 / (1 + math.Exp(-x))
+	}
+	e := math.Exp(x)
+	return e / (1 + e)
+}
+
+// FitPlatt fits a sigmoid to the detections in a validated set
+// by maximum likelihood with Platt's regularized targets.
+// Returns an error if there are no detections.
+//
+// Platt, "Probabilistic Outputs for Support Vector Machines
+// and Comparisons to Regularized Likelihood Methods", 1999.
+// Lin, Lin and Weng, "A note on Platt's probabilistic outputs
+// for support vector machines", Machine Learning 2007.
+func FitPlatt(set *ValSet) (*Platt, error) {
+	if len(set.Dets) == 0 {
+		return nil, errors.New("no detections")
+	}
+	var npos, nneg float64
+	for _, det := range set.Dets {
+		if det.True {
+			npos++
+		} else {
+			nneg++
+		}
+	}
+	hi, lo := (npos+1)/(npos+2), 1/(nneg+2)
+	target := func(det ValScore) float64 {
+		if det.True {
+			return hi
+		}
+		return lo
+	}
+	// Negative log-likelihood.
+	// Uses log(1 + exp(f)) = f + log(1 + exp(-f)) for stability.
+	nll := func(a, b float64) float64 {
+		var f float64
+		for _, det := range set.Dets {
+			z := a*det.Score + b
+			t := target(det)
+			if z >= 0 {
+				f += t*z + math.Log1p(math.Exp(-z))
+			} else {
+				f += (t-1)*z + math.Log1p(math.Exp(z))
+			}
+		}
+		return f
+	}
+
+	const (
+		maxIter = 100
+		minStep = 1e-10
+		sigma   = 1e-12
+		eps     = 1e-5
+	)
+	a, b := 0., math.Log((nneg+1)/(npos+1))
+	f := nll(a, b)
+	for iter := 0; iter < maxIter; iter++ {
+		// Gradient and Hessian (with small ridge).
+		var h11, h22, h21, g1, g2 float64 = sigma, sigma, 0, 0, 0
+		for _, det := range set.Dets {
+			z := a*det.Score + b
+			p := sigmoid(-z)
+			q := 1 - p
+			d2 := p * q
+			h11 += det.Score * det.Score * d2
+			h22 += d2
+			h21 += det.Score * d2
+			d1 := target(det) - p
+			g1 += det.Score * d1
+			g2 += d1
+		}
+		if math.Abs(g1) < eps && math.Abs(g2) < eps {
+			break
+		}
+		// Newton direction.
+		det := h11*h22 - h21*h21
+		da := -(h22*g1 - h21*g2) / det
+		db := -(-h21*g1 + h11*g2) / det
+		gd := g1*da + g2*db
+		// Backtracking line search.
+		step := 1.
+		for step >= minStep {
+			na, nb := a+step*da, b+step*db
+			if nf := nll(na, nb); nf < f+1e-4*step*gd {
+				a, b, f = na, nb, nf
+				break
+			}
+			step /= 2
+		}
+		if step < minStep {
+			break
+		}
+	}
+	return &Platt{a, b}, nil
+}
+
+// Isotonic maps a score to a probability using a non-decreasing
+// piecewise-linear function.
+// Scores outside the range of the breakpoints are clipped.
+type Isotonic struct {
+	// Breakpoints in increasing order of score.
+	Scores []float64
+	Values []float64
+}
+
+func (c *Isotonic) Calibrate(score float64) float64 {
+	n := len(c.Scores)
+	if n == 0 {
+		return math.NaN()
+	}
+	// First breakpoint which is not less than the score.
+	i := sort.SearchFloat64s(c.Scores, score)
+	if i == 0 {
+		return c.Values[0]
+	}
+	if i == n {
+		return c.Values[n-1]
+	}
+	s0, s1 := c.Scores[i-1], c.Scores[i]
+	v0, v1 := c.Values[i-1], c.Values[i]
+	return v0 + (score-s0)/(s1-s0)*(v1-v0)
+}
+
+// FitIsotonic fits a non-decreasing function to the detections in a validated set
+// by least squares using the pool-adjacent-violators algorithm.
+// Each block of pooled scores contributes a breakpoint at its least and greatest score.
+// Returns an error if there are no detections.
+func FitIsotonic(set *ValSet) (*Isotonic, error) {
+	if len(set.Dets) == 0 {
+		return nil, errors.New("no detections")
+	}
+	// Blocks in increasing order of score.
+	// Equal scores are pooled from the start.
+	type block struct {
+		Min, Max    float64
+		Sum, Weight float64
+	}
+	var blocks []block
+	for i := len(set.Dets) - 1; i >= 0; i-- {
+		det := set.Dets[i]
+		if math.IsNaN(det.Score) {
+			return nil, errors.New("score is NaN")
+		}
+		var y float64
+		if det.True {
+			y = 1
+		}
+		if n := len(blocks); n > 0 && blocks[n-1].Max == det.Score {
+			blocks[n-1].Sum += y
+			blocks[n-1].Weight++
+		} else if n > 0 && blocks[n-1].Max > det.Score {
+			return nil, errors.New("not sorted")
+		} else {
+			blocks = append(blocks, block{det.Score, det.Score, y, 1})
+		}
+		// Pool adjacent violators.
+		for n := len(blocks); n > 1; n = len(blocks) {
+			p, q := blocks[n-2], blocks[n-1]
+			if p.Sum/p.Weight < q.Sum/q.Weight {
+				break
+			}
+			blocks[n-2] = block{p.Min, q.Max, p.Sum + q.Sum, p.Weight + q.Weight}
+			blocks = blocks[:n-1]
+		}
+	}
+	c := new(Isotonic)
+	for _, b := range blocks {
+		v := b.Sum / b.Weight
+		c.Scores = append(c.Scores, b.Min)
+		c.Values = append(c.Values, v)
+		if b.Max > b.Min {
+			c.Scores = append(c.Scores, b.Max)
+			c.Values = append(c.Values, v)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,90 @@
+package detect_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+// Samples scores and labels from a logistic model
+// 	p(true | s) = 1 / (1 + exp(a s + b)).
+func logisticSet(n int, a, b float64, r *rand.Rand) *detect.ValSet {
+	dets := make([]detect.ValScore, n)
+	for i := range dets {
+		s := r.NormFloat64() * 2
+		p := 1 / (1 + math.Exp(a*s+b))
+		dets[i] = detect.ValScore{s, r.Float64() < p}
+	}
+	return detect.MergeValSets(&detect.ValSet{Dets: dets})
+}
+
+func TestFitPlatt(t *testing.T) {
+	const a, b = -1.5, 0.5
+	set := logisticSet(20000, a, b, rand.New(rand.NewSource(1)))
+	c, err := detect.FitPlatt(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(c.A-a) > 0.1 || math.Abs(c.B-b) > 0.1 {
+		t.Errorf("want (%g, %g), got (%g, %g)", a, b, c.A, c.B)
+	}
+}
+
+func TestFitIsotonic(t *testing.T) {
+	// Scores in increasing order with labels.
+	scores := []float64{1, 2, 3, 4, 5, 6}
+	labels := []bool{false, true, false, false, true, true}
+	set := new(detect.ValSet)
+	for i := len(scores) - 1; i >= 0; i-- {
+		set.Dets = append(set.Dets, detect.ValScore{scores[i], labels[i]})
+	}
+	c, err := detect.FitIsotonic(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Blocks {1}, {2, 3, 4}, {5, 6} with means 0, 1/3, 1.
+	cases := []struct{ In, Out float64 }{
+		{0, 0},
+		{1, 0},
+		{1.5, 1. / 6},
+		{3, 1. / 3},
+		{4.5, 2. / 3},
+		{6, 1},
+		{10, 1},
+	}
+	for _, x := range cases {
+		if got := c.Calibrate(x.In); math.Abs(got-x.Out) > 1e-9 {
+			t.Errorf("at %g: want %g, got %g", x.In, x.Out, got)
+		}
+	}
+	if !sort.Float64sAreSorted(c.Values) {
+		t.Errorf("values are not non-decreasing: %v", c.Values)
+	}
+}
+
+func TestCalibMarshaler(t *testing.T) {
+	set := logisticSet(200, -1, 0, rand.New(rand.NewSource(1)))
+	for _, method := range []string{"platt", "isotonic"} {
+		c, err := detect.FitCalib(set, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := new(detect.CalibMarshaler)
+		if err := json.Unmarshal(buf, d); err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []float64{-3, -0.5, 0, 0.7, 3} {
+			if want, got := c.Calibrate(s), d.Calibrate(s); want != got {
+				t.Errorf("%s at %g: want %g, got %g", method, s, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package detect
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+)
+
+// LabelDet is a detection of a particular class.
+type LabelDet struct {
+	Det
+	Class string
+}
+
+// Label assigns a class to every detection in a list.
+func Label(dets []Det, class string) []LabelDet {
+	labels := make([]LabelDet, len(dets))
+	for i, det := range dets {
+		labels[i] = LabelDet{det, class}
+	}
+	return labels
+}
+
+// SortLabel sorts a list of labelled detections descending by score.
+func SortLabel(dets []LabelDet) {
+	for _, det := range dets {
+		if math.IsNaN(det.Score) {
+			panic("cannot sort scores: NaN")
+		}
+	}
+	sort.Sort(labelDetsByScoreDesc(dets))
+}
+
+type labelDetsByScoreDesc []LabelDet
+
+func (s labelDetsByScoreDesc) Len() int           { return len(s) }
+func (s labelDetsByScoreDesc) Less(i, j int) bool { return s[i].Score > s[j].Score }
+func (s labelDetsByScoreDesc) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// LabelDetSlice wraps []LabelDet to satisfy the DetList interface.
+type LabelDetSlice []LabelDet
+
+func (dets LabelDetSlice) Len() int     { return len(dets) }
+func (dets LabelDetSlice) At(i int) Det { return dets[i].Det }
+
+// SuppressClass performs non-max suppression on a sorted list of labelled detections.
+// Detections of different classes never suppress one another.
+//
+// The limit on the total number of detections to keep is ignored if non-positive.
+func SuppressClass(dets []LabelDet, maxnum int, overlap OverlapFunc) []LabelDet {
+	if !sort.IsSorted(labelDetsByScoreDesc(dets)) {
+		panic("not sorted")
+	}
+	// Suppress within each class independently.
+	keep := make([]bool, len(dets))
+	for _, inds := range classIndex(dets) {
+		sub := make(DetSlice, len(inds))
+		for k, i := range inds {
+			sub[k] = dets[i].Det
+		}
+		for _, k := range SuppressIndex(sub, maxnum, overlap) {
+			keep[inds[k]] = true
+		}
+	}
+	// Take detections in order of score.
+	var subset []LabelDet
+	for i, det := range dets {
+		if !keep[i] {
+			continue
+		}
+		if maxnum > 0 && len(subset) >= maxnum {
+			break
+		}
+		subset = append(subset, det)
+	}
+	return subset
+}
+
+// Returns the indices of the detections of each class in order.
+func classIndex(dets []LabelDet) map[string][]int {
+	index := make(map[string][]int)
+	for i, det := range dets {
+		index[det.Class] = append(index[det.Class], i)
+	}
+	return index
+}
+
+// LabelRect is a ground-truth region of a particular class.
+type LabelRect struct {
+	Class string
+	Rect  image.Rectangle
+}
+
+func rectsOfClass(rects []LabelRect, class string) []image.Rectangle {
+	var sub []image.Rectangle
+	for _, r := range rects {
+		if r.Class == class {
+			sub = append(sub, r.Rect)
+		}
+	}
+	return sub
+}
+
+// ValidateClasses validates the detections of each class independently.
+// The detections must be ordered (descending) by score.
+// Detections are only compared to references and ignore regions of the same class.
+// Returns a validated image for every class in the list,
+// even if it has no detections or references.
+// Classes which are not in the list are discarded.
+func ValidateClasses(dets []LabelDet, refs, ignore []LabelRect, classes []string, refMinIOU, ignoreMinCover float64) map[string]*ValImage {
+	vals := make(map[string]*ValImage)
+	for _, class := range classes {
+		var sub []Det
+		for _, det := range dets {
+			if det.Class == class {
+				sub = append(sub, det.Det)
+			}
+		}
+		vals[class] = Validate(sub, rectsOfClass(refs, class), rectsOfClass(ignore, class), refMinIOU, ignoreMinCover)
+	}
+	return vals
+}
+
+// ClassValSets contains the validated set of each class.
+type ClassValSets map[string]*ValSet
+
+// ClassSets returns the set of each class in an image.
+func ClassSets(vals map[string]*ValImage) ClassValSets {
+	sets := make(ClassValSets)
+	for class, val := range vals {
+		sets[class] = val.Set()
+	}
+	return sets
+}
+
+// MergeClassValSets combines the sets of each class.
+func MergeClassValSets(sets ...ClassValSets) ClassValSets {
+	byClass := make(map[string][]*ValSet)
+	for _, set := range sets {
+		for class, s := range set {
+			byClass[class] = append(byClass[class], s)
+		}
+	}
+	merged := make(ClassValSets)
+	for class, s := range byClass {
+		merged[class] = MergeValSets(s...)
+	}
+	return merged
+}
+
+// AvgPrecs computes the average precision of each class
+// using a function such as AvgPrec or AvgPrec11.
+func (sets ClassValSets) AvgPrecs(ap func(*ValSet) float64) map[string]float64 {
+	aps := make(map[string]float64)
+	for class, set := range sets {
+		aps[class] = ap(set)
+	}
+	return aps
+}
+
+// MeanAvgPrec computes the mean over classes of the average precision
+// using a function such as AvgPrec or AvgPrec11.
+// Classes without any positive instances are excluded.
+// Returns NaN if no class has positive instances.
+func (sets ClassValSets) MeanAvgPrec(ap func(*ValSet) float64) float64 {
+	var x []float64
+	for _, v := range sets.AvgPrecs(ap) {
+		x = append(x, v)
+	}
+	return mean(x)
+}
+
+// ClassPair identifies the class of a detection
+// and the class of the object which it matched.
+type ClassPair struct {
+	Det, Ref string
+}
+
+// Confusion counts the detections of one class
+// which matched objects of another class.
+type Confusion map[ClassPair]int
+
+// Confuse finds the detections in an image which are false positives
+// for their own class but overlap an object of another class.
+// Detections are first validated against their own class
+// using a greedy match.
+// Each remaining detection is then counted against the object of a different class
+// with which it has the greatest intersection-over-union,
+// provided that this is at least minIOU.
+func Confuse(dets []LabelDet, refs []LabelRect, minIOU float64) Confusion {
+	conf := make(Confusion)
+	for class, inds := range classIndex(dets) {
+		sub := make(DetSlice, len(inds))
+		for k, i := range inds {
+			sub[k] = dets[i].Det
+		}
+		m := Match(sub, rectsOfClass(refs, class), minIOU)
+		for k, det := range sub {
+			if _, ok := m[k]; ok {
+				continue
+			}
+			var (
+				best  float64
+				other string
+			)
+			for _, ref := range refs {
+				if ref.Class == class {
+					continue
+				}
+				if inter := IOU(det.Rect, ref.Rect); inter >= minIOU && inter > best {
+					best, other = inter, ref.Class
+				}
+			}
+			if best > 0 {
+				conf[ClassPair{class, other}]++
+			}
+		}
+	}
+	return conf
+}
+
+// Add adds the counts of another confusion report.
+func (conf Confusion) Add(other Confusion) {
+	for pair, n := range other {
+		conf[pair] += n
+	}
+}
+
+// String lists the pairs in order of decreasing count.
+func (conf Confusion) String() string {
+	pairs := make([]ClassPair, 0, len(conf))
+	for pair := range conf {
+		pairs = append(pairs, pair)
+	}
+	sort.Sort(pairsByCountDesc{pairs, conf})
+	lines := make([]string, len(pairs))
+	for i, pair := range pairs {
+		lines[i] = fmt.Sprintf("%s as %s: %d", pair.Ref, pair.Det, conf[pair])
+	}
+	return strings.Join(lines, "\n")
+}
+
+type pairsByCountDesc struct {
+	Pairs []ClassPair
+	Conf  Confusion
+}
+
+func (s pairsByCountDesc) Len() int      { return len(s.Pairs) }
+func (s pairsByCountDesc) Swap(i, j int) { s.Pairs[i], s.Pairs[j] = s.Pairs[j], s.Pairs[i] }
+
+func (s pairsByCountDesc) Less(i, j int) bool {
+	a, b := s.Pairs[i], s.Pairs[j]
+	if s.Conf[a] != s.Conf[b] {
+		return s.Conf[a] > s.Conf[b]
+	}
+	if a.Det != b.Det {
+		return a.Det < b.Det
+	}
+	return a.Ref < b.Ref
+}
@@ -0,0 +1,65 @@
+package detect_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+func TestSuppressClass(t *testing.T) {
+	a := image.Rect(0, 0, 100, 100)
+	b := image.Rect(10, 0, 110, 100)
+	dets := []detect.LabelDet{
+		{detect.Det{4, a}, "cat"},
+		{detect.Det{3, b}, "dog"},
+		{detect.Det{2, b}, "cat"},
+		{detect.Det{1, a}, "dog"},
+	}
+	overlap := func(a, b image.Rectangle) bool { return detect.IOU(a, b) > 0.5 }
+	got := detect.SuppressClass(dets, 0, overlap)
+	if len(got) != 2 || got[0] != dets[0] || got[1] != dets[1] {
+		t.Errorf("want first two detections, got %v", got)
+	}
+	got = detect.SuppressClass(dets, 1, overlap)
+	if len(got) != 1 || got[0] != dets[0] {
+		t.Errorf("max one: want first detection, got %v", got)
+	}
+}
+
+func TestValidateClasses(t *testing.T) {
+	classes := []string{"cat", "dog", "bird"}
+	cat := image.Rect(0, 0, 100, 100)
+	dog := image.Rect(200, 0, 300, 100)
+	refs := []detect.LabelRect{{"cat", cat}, {"dog", dog}}
+	dets := []detect.LabelDet{
+		// Correct cat.
+		{detect.Det{3, cat}, "cat"},
+		// Dog detected as cat.
+		{detect.Det{2, dog}, "cat"},
+		// Correct dog.
+		{detect.Det{1, dog}, "dog"},
+	}
+	vals := detect.ValidateClasses(dets, refs, nil, classes, 0.5, 0.5)
+	if len(vals) != len(classes) {
+		t.Fatalf("want %d classes, got %d", len(classes), len(vals))
+	}
+	sets := detect.MergeClassValSets(detect.ClassSets(vals), detect.ClassSets(vals))
+	if n := sets["bird"].Images; n != 2 {
+		t.Errorf("empty class: want 2 images, got %d", n)
+	}
+	aps := sets.AvgPrecs(detect.AvgPrec)
+	if aps["cat"] != 1 || aps["dog"] != 1 {
+		t.Errorf("want AP of 1, got %v", aps)
+	}
+	// Bird has no instances and is excluded.
+	if m := sets.MeanAvgPrec(detect.AvgPrec); math.Abs(m-1) > 1e-9 {
+		t.Errorf("mAP: want 1, got %g", m)
+	}
+
+	conf := detect.Confuse(dets, refs, 0.5)
+	if len(conf) != 1 || conf[detect.ClassPair{Det: "cat", Ref: "dog"}] != 1 {
+		t.Errorf("want one dog detected as cat, got %v", conf)
+	}
+}
@@ -0,0 +1,264 @@
+package detect
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// COCOImage is the input to the COCO evaluation for one image.
+type COCOImage struct {
+	// Detections ordered (descending) by score.
+	Dets []Det
+	// Ground-truth objects.
+	Refs []image.Rectangle
+	// Crowd regions, which are ignored.
+	// A crowd region can match any number of detections.
+	Crowd []image.Rectangle
+}
+
+// AreaRange is an inclusive range of object areas in pixels.
+type AreaRange struct {
+	Min, Max float64
+}
+
+// Contains returns whether the area of a rectangle is within the range.
+func (a AreaRange) Contains(r image.Rectangle) bool {
+	x := float64(area(r))
+	return a.Min <= x && x <= a.Max
+}
+
+// Object sizes used in the COCO evaluation.
+var (
+	AreaAll    = AreaRange{0, math.Inf(1)}
+	AreaSmall  = AreaRange{0, 32 * 32}
+	AreaMedium = AreaRange{32 * 32, 96 * 96}
+	AreaLarge  = AreaRange{96 * 96, math.Inf(1)}
+)
+
+// COCOIOUs returns the IOU thresholds 0.50, 0.55, ..., 0.95.
+func COCOIOUs() []float64 {
+	t := make([]float64, 10)
+	for i := range t {
+		t[i] = 0.5 + 0.05*float64(i)
+	}
+	return t
+}
+
+// COCOResult contains the summary statistics of the COCO evaluation.
+// AP is averaged over the IOU thresholds 0.50, 0.55, ..., 0.95
+// unless otherwise specified.
+// AR is the maximum recall with a fixed number of detections per image,
+// averaged over the same thresholds.
+// Statistics are NaN if there were no objects.
+type COCOResult struct {
+	AP, AP50, AP75             float64
+	APSmall, APMedium, APLarge float64
+	AR1, AR10, AR100           float64
+	ARSmall, ARMedium, ARLarge float64
+}
+
+func (r *COCOResult) String() string {
+	var s string
+	for _, x := range []struct {
+		Name  string
+		Value float64
+	}{
+		{"AP @[ IoU=0.50:0.95 | area=   all | maxDets=100 ]", r.AP},
+		{"AP @[ IoU=0.50      | area=   all | maxDets=100 ]", r.AP50},
+		{"AP @[ IoU=0.75      | area=   all | maxDets=100 ]", r.AP75},
+		{"AP @[ IoU=0.50:0.95 | area= small | maxDets=100 ]", r.APSmall},
+		{"AP @[ IoU=0.50:0.95 | area=medium | maxDets=100 ]", r.APMedium},
+		{"AP @[ IoU=0.50:0.95 | area= large | maxDets=100 ]", r.APLarge},
+		{"AR @[ IoU=0.50:0.95 | area=   all | maxDets=  1 ]", r.AR1},
+		{"AR @[ IoU=0.50:0.95 | area=   all | maxDets= 10 ]", r.AR10},
+		{"AR @[ IoU=0.50:0.95 | area=   all | maxDets=100 ]", r.AR100},
+		{"AR @[ IoU=0.50:0.95 | area= small | maxDets=100 ]", r.ARSmall},
+		{"AR @[ IoU=0.50:0.95 | area=medium | maxDets=100 ]", r.ARMedium},
+		{"AR @[ IoU=0.50:0.95 | area= large | maxDets=100 ]", r.ARLarge},
+	} {
+		s += fmt.Sprintf("%s = %.3f\n", x.Name, x.Value)
+	}
+	return s
+}
+
+// EvalCOCO computes the COCO statistics of a set of images.
+//
+// At most 100 detections are considered in each image.
+// Objects outside the area range are ignored,
+// as are unmatched detections outside the area range.
+// Detections which are covered by a crowd region
+// more than the IOU threshold are ignored.
+func EvalCOCO(ims []COCOImage) *COCOResult {
+	const maxDets = 100
+	ious := COCOIOUs()
+	// Validate every image at every threshold for every area range.
+	areas := []AreaRange{AreaAll, AreaSmall, AreaMedium, AreaLarge}
+	// vals[a][t][i] is the validation of image i at threshold t for area a.
+	vals := make([][][]*ValImage, len(areas))
+	for a, area := range areas {
+		vals[a] = make([][]*ValImage, len(ious))
+		for t, iou := range ious {
+			vals[a][t] = make([]*ValImage, len(ims))
+			for i, im := range ims {
+				dets := im.Dets
+				if len(dets) > maxDets {
+					dets = dets[:maxDets]
+				}
+				vals[a][t][i] = ValidateCOCO(dets, im.Refs, im.Crowd, iou, area)
+			}
+		}
+	}
+
+	var r COCOResult
+	// Average precision and recall over thresholds.
+	avgPrec := func(a, maxDets int) float64 {
+		var x []float64
+		for t := range ious {
+			x = append(x, AvgPrecCOCO(cocoValSet(vals[a][t], maxDets)))
+		}
+		return mean(x)
+	}
+	avgRecall := func(a, maxDets int) float64 {
+		var x []float64
+		for t := range ious {
+			x = append(x, maxRecall(cocoValSet(vals[a][t], maxDets)))
+		}
+		return mean(x)
+	}
+	r.AP = avgPrec(0, maxDets)
+	r.AP50 = AvgPrecCOCO(cocoValSet(vals[0][0], maxDets))
+	r.AP75 = AvgPrecCOCO(cocoValSet(vals[0][5], maxDets))
+	r.APSmall = avgPrec(1, maxDets)
+	r.APMedium = avgPrec(2, maxDets)
+	r.APLarge = avgPrec(3, maxDets)
+	r.AR1 = avgRecall(0, 1)
+	r.AR10 = avgRecall(0, 10)
+	r.AR100 = avgRecall(0, maxDets)
+	r.ARSmall = avgRecall(1, maxDets)
+	r.ARMedium = avgRecall(2, maxDets)
+	r.ARLarge = avgRecall(3, maxDets)
+	return &r
+}
+
+// ValidateCOCO validates the detections in one image
+// following the rules of the COCO evaluation.
+// The detections must be ordered (descending) by score.
+//
+// Detections are first matched to the objects within the area range
+// and then to the objects outside it, which are ignored.
+// Unmatched detections are ignored if they are covered by a crowd region
+// or are themselves outside the area range.
+// Objects outside the area range are never counted as misses.
+func ValidateCOCO(dets []Det, refs, crowd []image.Rectangle, minIOU float64, area AreaRange) *ValImage {
+	var in, out []image.Rectangle
+	for _, ref := range refs {
+		if area.Contains(ref) {
+			in = append(in, ref)
+		} else {
+			out = append(out, ref)
+		}
+	}
+	vals, miss := ValidateList(DetSlice(dets), in, crowd, minIOU, minIOU)
+	// Match remaining false positives to objects outside the range.
+	var rest []int
+	for i, val := range vals {
+		if !val.True && !val.Ignore {
+			rest = append(rest, i)
+		}
+	}
+	restDets := make([]Det, len(rest))
+	for k, i := range rest {
+		restDets[k] = dets[i]
+	}
+	m := Match(DetSlice(restDets), out, minIOU)
+	for k, i := range rest {
+		if _, ok := m[k]; ok || !area.Contains(dets[i].Rect) {
+			vals[i] = Val{Ignore: true}
+		}
+	}
+	valdets := make([]ValDet, len(dets))
+	for i := range dets {
+		valdets[i] = ValDet{dets[i], vals[i]}
+	}
+	return &ValImage{valdets, miss}
+}
+
+// Merges the images using at most n detections per image.
+// References which are matched by subsequent detections become misses.
+func cocoValSet(ims []*ValImage, n int) *ValSet {
+	sets := make([]*ValSet, len(ims))
+	for i, im := range ims {
+		numPos := numTrue(im.Scores()) + len(im.Misses)
+		dets := im.Dets
+		if len(dets) > n {
+			dets = dets[:n]
+		}
+		sub := &ValImage{Dets: dets}
+		scores := sub.Scores()
+		sets[i] = &ValSet{scores, numPos - numTrue(scores), 1}
+	}
+	return MergeValSets(sets...)
+}
+
+// AvgPrecCOCO computes the average precision as in the COCO evaluation.
+// This is the mean of the interpolated precision at recall
+// 0, 0.01, ..., 1.
+// Precision is interpolated by taking the maximum precision at any greater recall
+// and is zero at recall which is not achieved.
+// Returns NaN if there are no positive instances.
+func AvgPrecCOCO(valset *ValSet) float64 {
+	if numTrue(valset.Dets)+valset.Misses == 0 {
+		return math.NaN()
+	}
+	prec, rec := PrecRecall(valset)
+	interp := interpPrec(prec)
+	var (
+		ap float64
+		j  int
+	)
+	const n = 101
+	for i := 0; i < n; i++ {
+		t := float64(i) / (n - 1)
+		// Find first operating point which achieves recall.
+		for j < len(rec) && rec[j] < t {
+			j++
+		}
+		if j == len(rec) {
+			break
+		}
+		ap += interp[j]
+	}
+	return ap / n
+}
+
+// Returns the recall with all detections,
+// or NaN if there are no positive instances.
+func maxRecall(valset *ValSet) float64 {
+	truePos := numTrue(valset.Dets)
+	numPos := truePos + valset.Misses
+	if numPos == 0 {
+		return math.NaN()
+	}
+	return float64(truePos) / float64(numPos)
+}
+
+// Computes the mean of the elements which are not NaN.
+// Returns NaN if there are none.
+func mean(x []float64) float64 {
+	var (
+		sum float64
+		n   int
+	)
+	for _, xi := range x {
+		if math.IsNaN(xi) {
+			continue
+		}
+		sum += xi
+		n++
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return sum / float64(n)
+}
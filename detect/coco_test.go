@@ -0,0 +1,74 @@
+package detect_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+func TestAvgPrecCOCO(t *testing.T) {
+	valset := &detect.ValSet{
+		Dets:   []detect.ValScore{{4, true}, {3, false}, {2, true}, {1, false}},
+		Misses: 2,
+		Images: 1,
+	}
+	// Recall 0, ..., 0.25 achieves 1, recall 0.26, ..., 0.5 achieves 2/3.
+	want := (26 + 25*2.0/3) / 101
+	if got := detect.AvgPrecCOCO(valset); math.Abs(got-want) > 1e-9 {
+		t.Errorf("want %.6g, got %.6g", want, got)
+	}
+}
+
+func TestEvalCOCO(t *testing.T) {
+	large := image.Rect(0, 0, 100, 200)
+	small := image.Rect(300, 0, 310, 20)
+	crowd := image.Rect(400, 0, 600, 200)
+	ims := []detect.COCOImage{
+		{
+			Dets: []detect.Det{
+				// Small object scores higher than large object.
+				{10, small},
+				{9, large},
+				// Inside crowd region.
+				{8, image.Rect(450, 50, 550, 150)},
+			},
+			Refs:  []image.Rectangle{large, small},
+			Crowd: []image.Rectangle{crowd},
+		},
+		{
+			Dets: []detect.Det{{5, large}},
+			Refs: []image.Rectangle{large},
+		},
+	}
+	r := detect.EvalCOCO(ims)
+	for _, x := range []struct {
+		Name      string
+		Got, Want float64
+	}{
+		{"AP", r.AP, 1},
+		{"AP50", r.AP50, 1},
+		{"AP75", r.AP75, 1},
+		{"APSmall", r.APSmall, 1},
+		{"APLarge", r.APLarge, 1},
+		// Top detection in first image finds one of two objects.
+		{"AR1", r.AR1, 2.0 / 3},
+		{"AR10", r.AR10, 1},
+		{"AR100", r.AR100, 1},
+	} {
+		if math.Abs(x.Got-x.Want) > 1e-9 {
+			t.Errorf("%s: want %.6g, got %.6g", x.Name, x.Want, x.Got)
+		}
+	}
+	if !math.IsNaN(r.APMedium) {
+		t.Errorf("APMedium: want NaN, got %g", r.APMedium)
+	}
+
+	// False positive with highest score.
+	ims[1].Dets = append([]detect.Det{{20, image.Rect(200, 200, 300, 300)}}, ims[1].Dets...)
+	r = detect.EvalCOCO(ims)
+	if !(r.AP < 1) {
+		t.Errorf("AP with false positive: want < 1, got %g", r.AP)
+	}
+}
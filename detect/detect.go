@@ -56,6 +56,13 @@ type DetFilter struct {
 	LocalMax bool
 	// Score threshold.
 	MinScore float64
+	// Ignore detections which are smaller than a neighbor
+	// in the same or an adjacent level of a pyramid?
+	// The neighborhood is a square of radius ScaleRadius in the feature image.
+	// Positions in adjacent levels are mapped through the image coordinates.
+	// Only used by MultiScale and Pyramid.
+	ScaleMax    bool
+	ScaleRadius int
 }
 
 // DetPos describes a scored position.
@@ -74,8 +81,15 @@ func Points(im *rimg64.Multi, scorer slide.Scorer, localmax bool, minscore float
 	if err != nil {
 		return nil, err
 	}
+	return RespPoints(resp, localmax, minscore), nil
+}
+
+// RespPoints finds the scored positions in a response image.
+// It applies the same criteria as Points.
+// Returns nil if resp is nil.
+func RespPoints(resp *rimg64.Image, localmax bool, minscore float64) []DetPos {
 	if resp == nil {
-		return nil, nil
+		return nil
 	}
 	var dets []DetPos
 	// Iterate over positions and check criteria.
@@ -88,11 +102,10 @@ func Points(im *rimg64.Multi, scorer slide.Scorer, localmax bool, minscore float
 			if localmax && notLocalMax(resp, u, v) {
 				continue
 			}
-			// Scale by rate, then apply margin and interior offsets.
 			dets = append(dets, DetPos{score, image.Pt(u, v)})
 		}
 	}
-	return dets, nil
+	return dets
 }
 
 // Converts the position of a detection in a feature image to a rectangle in the intensity image.
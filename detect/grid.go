@@ -0,0 +1,155 @@
+package detect
+
+import (
+	"image"
+	"sort"
+)
+
+// SuppressGrid performs non-max suppression on a sorted list of detections
+// using a spatial index.
+// See SuppressIndexGrid.
+func SuppressGrid(dets []Det, maxnum int, overlap OverlapFunc) []Det {
+	inds := SuppressIndexGrid(DetSlice(dets), maxnum, overlap)
+	subset := make([]Det, len(inds))
+	for i, ind := range inds {
+		subset[i] = dets[ind]
+	}
+	return subset
+}
+
+// GridSupprFilter specifies parameters to SuppressGrid.
+// It satisfies Suppressor so that it can be used by MultiScale.
+type GridSupprFilter SupprFilter
+
+// Suppress calls SuppressGrid.
+func (f GridSupprFilter) Suppress(dets []Det) []Det {
+	return SuppressGrid(dets, f.MaxNum, f.Overlap)
+}
+
+// SuppressIndexGrid returns the same result as SuppressIndex
+// provided that rectangles which do not intersect never overlap.
+// This is true of IOU and Cover with a positive threshold.
+//
+// Each detection is only compared to the detections which have been kept
+// and which share a cell in a grid.
+// The kept detections are stored in a grid at each power-of-two scale
+// so that every detection occupies at most four cells.
+func SuppressIndexGrid(dets DetList, maxnum int, overlap OverlapFunc) []int {
+	if !sort.IsSorted(detListByScoreDesc{dets}) {
+		panic("not sorted")
+	}
+	var (
+		subset []int
+		grids  = make(map[uint]*supprGrid)
+		// Last query in which each kept detection was checked.
+		seen []int
+	)
+	for i := 0; i < dets.Len(); i++ {
+		if maxnum > 0 && len(subset) >= maxnum {
+			break
+		}
+		r := dets.At(i).Rect
+		if isSuppressed(dets, r, i, subset, grids, seen, overlap) {
+			continue
+		}
+		subset = append(subset, i)
+		seen = append(seen, -1)
+		if r.Empty() {
+			// Cannot intersect anything.
+			continue
+		}
+		level := gridLevel(r)
+		g := grids[level]
+		if g == nil {
+			g = &supprGrid{Size: 1 << level, Cells: make(map[image.Point][]int)}
+			grids[level] = g
+		}
+		g.add(r, len(subset)-1)
+	}
+	return subset
+}
+
+// Checks whether r is suppressed by any of the kept detections.
+// The index k of a kept detection refers to subset[k].
+func isSuppressed(dets DetList, r image.Rectangle, query int, subset []int, grids map[uint]*supprGrid, seen []int, overlap OverlapFunc) bool {
+	if r.Empty() {
+		return false
+	}
+	check := func(k int) bool {
+		if seen[k] == query {
+			return false
+		}
+		seen[k] = query
+		return overlap(dets.At(subset[k]).Rect, r)
+	}
+	for _, g := range grids {
+		cells := g.cells(r)
+		if cells.Dx()*cells.Dy() > len(g.Cells) {
+			// Cheaper to visit every occupied cell.
+			for cell, ks := range g.Cells {
+				if !cell.In(cells) {
+					continue
+				}
+				for _, k := range ks {
+					if check(k) {
+						return true
+					}
+				}
+			}
+			continue
+		}
+		for x := cells.Min.X; x < cells.Max.X; x++ {
+			for y := cells.Min.Y; y < cells.Max.Y; y++ {
+				for _, k := range g.Cells[image.Pt(x, y)] {
+					if check(k) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Grid of square cells containing rectangles no larger than the cells.
+type supprGrid struct {
+	Size  int
+	Cells map[image.Point][]int
+}
+
+func (g *supprGrid) add(r image.Rectangle, k int) {
+	cells := g.cells(r)
+	for x := cells.Min.X; x < cells.Max.X; x++ {
+		for y := cells.Min.Y; y < cells.Max.Y; y++ {
+			p := image.Pt(x, y)
+			g.Cells[p] = append(g.Cells[p], k)
+		}
+	}
+}
+
+// Returns the range of cells which contain a pixel of r.
+func (g *supprGrid) cells(r image.Rectangle) image.Rectangle {
+	return image.Rect(
+		floorDiv(r.Min.X, g.Size), floorDiv(r.Min.Y, g.Size),
+		floorDiv(r.Max.X-1, g.Size)+1, floorDiv(r.Max.Y-1, g.Size)+1,
+	)
+}
+
+// Returns the smallest level such that 2^level is at least
+// the width and height of r.
+func gridLevel(r image.Rectangle) uint {
+	n := max(r.Dx(), r.Dy())
+	var level uint
+	for 1<<level < n {
+		level++
+	}
+	return level
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,69 @@
+package detect_test
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+// Generates random detections at multiple scales, sorted by score.
+func randDets(n int, r *rand.Rand) []detect.Det {
+	dets := make([]detect.Det, n)
+	for i := range dets {
+		h := int(16 * math.Pow(2, 4*r.Float64()))
+		w := h / 2
+		x, y := r.Intn(640+w)-w, r.Intn(480+h)-h
+		dets[i] = detect.Det{r.NormFloat64(), image.Rect(x, y, x+w, y+h)}
+	}
+	detect.Sort(dets)
+	return dets
+}
+
+func TestSuppressIndexGrid(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	overlaps := map[string]detect.OverlapFunc{
+		"iou":   func(a, b image.Rectangle) bool { return detect.IOU(a, b) > 0.3 },
+		"cover": func(a, b image.Rectangle) bool { return detect.Cover(a, b) > 0.5 },
+	}
+	for name, overlap := range overlaps {
+		for _, maxnum := range []int{0, 10} {
+			for trial := 0; trial < 10; trial++ {
+				dets := randDets(1000, r)
+				want := detect.SuppressIndex(detect.DetSlice(dets), maxnum, overlap)
+				got := detect.SuppressIndexGrid(detect.DetSlice(dets), maxnum, overlap)
+				if !reflect.DeepEqual(want, got) {
+					t.Errorf("%s, max %d: different result\nwant %v\ngot  %v", name, maxnum, want, got)
+				}
+			}
+		}
+	}
+}
+
+func benchmarkSuppress(b *testing.B, n int, suppr func(detect.DetList, int, detect.OverlapFunc) []int) {
+	dets := randDets(n, rand.New(rand.NewSource(1)))
+	overlap := func(a, b image.Rectangle) bool { return detect.IOU(a, b) > 0.3 }
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		suppr(detect.DetSlice(dets), 0, overlap)
+	}
+}
+
+func BenchmarkSuppressIndex_1000(b *testing.B) {
+	benchmarkSuppress(b, 1000, detect.SuppressIndex)
+}
+
+func BenchmarkSuppressIndex_10000(b *testing.B) {
+	benchmarkSuppress(b, 10000, detect.SuppressIndex)
+}
+
+func BenchmarkSuppressIndexGrid_1000(b *testing.B) {
+	benchmarkSuppress(b, 1000, detect.SuppressIndexGrid)
+}
+
+func BenchmarkSuppressIndexGrid_10000(b *testing.B) {
+	benchmarkSuppress(b, 10000, detect.SuppressIndexGrid)
+}
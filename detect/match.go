@@ -0,0 +1,231 @@
+package detect
+
+import (
+	"image"
+	"math"
+)
+
+// MatchStrategy decides how detections are assigned to references
+// in ValidateMatch and ValidateListMatch.
+//
+// Every strategy considers the references before the ignore regions:
+// a detection is only compared to the ignore regions
+// if it was not assigned to a reference.
+type MatchStrategy int
+
+const (
+	// Each detection, in order of score, is assigned to the
+	// unassigned reference with which it overlaps the most.
+	// This is the strategy of Validate and ValidateList.
+	MatchGreedy MatchStrategy = iota
+	// Detections are assigned to maximize the total overlap.
+	// The result does not depend on the order of the detections.
+	MatchOptimal
+	// Like MatchGreedy, except that detections which are covered
+	// by an ignore region are only assigned to the references
+	// which remain after all other detections have been assigned.
+	// This prevents an ignored detection from taking a reference
+	// which another detection could have matched.
+	MatchPreferRefs
+)
+
+// MatchOptimalIOU takes a list of detections and a list of ground truth regions.
+// Finds the one-to-one assignment which maximizes the total intersection-over-union,
+// considering only pairs whose overlap is at least mininter.
+// Returns a map from detection index to reference index.
+//
+// The scores of the detections are not used.
+// Detections and references without a sufficient overlap are discarded
+// and the assignment is solved independently for each connected component
+// of the remaining pairs.
+func MatchOptimalIOU(dets DetList, refs []image.Rectangle, mininter float64) map[int]int {
+	n, m := dets.Len(), len(refs)
+	// Find the pairs with sufficient overlap.
+	// Nodes 0, ..., n-1 are detections and n, ..., n+m-1 are references.
+	type edge struct {
+		I, J  int
+		Inter float64
+	}
+	var edges []edge
+	comp := newUnionFind(n + m)
+	for i := 0; i < n; i++ {
+		det := dets.At(i)
+		for j := 0; j < m; j++ {
+			// Pairs without overlap cannot improve the total.
+			if inter := IOU(det.Rect, refs[j]); inter > 0 && inter >= mininter {
+				edges = append(edges, edge{i, j, inter})
+				comp.union(i, n+j)
+			}
+		}
+	}
+	// Group the edges by component.
+	groups := make(map[int][]edge)
+	var roots []int
+	for _, e := range edges {
+		root := comp.find(e.I)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], e)
+	}
+
+	match := make(map[int]int)
+	for _, root := range roots {
+		group := groups[root]
+		// Number the detections and references in the component.
+		detIndex, refIndex := make(map[int]int), make(map[int]int)
+		var detOf, refOf []int
+		for _, e := range group {
+			if _, ok := detIndex[e.I]; !ok {
+				detIndex[e.I] = len(detOf)
+				detOf = append(detOf, e.I)
+			}
+			if _, ok := refIndex[e.J]; !ok {
+				refIndex[e.J] = len(refOf)
+				refOf = append(refOf, e.J)
+			}
+		}
+		// Pairs with insufficient overlap have the same cost as no match.
+		k := max(len(detOf), len(refOf))
+		cost := make([][]float64, k)
+		for p := range cost {
+			cost[p] = make([]float64, k)
+		}
+		for _, e := range group {
+			cost[detIndex[e.I]][refIndex[e.J]] = -e.Inter
+		}
+		assign := hungarian(cost)
+		for p, i := range detOf {
+			q := assign[p]
+			if q < len(refOf) && cost[p][q] < 0 {
+				match[i] = refOf[q]
+			}
+		}
+	}
+	return match
+}
+
+// Disjoint-set forest with path compression.
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	parent := make(unionFind, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	return parent
+}
+
+func (u unionFind) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+func (u unionFind) union(i, j int) {
+	u[u.find(i)] = u.find(j)
+}
+
+func matchPreferRefs(dets DetList, refs, ignore []image.Rectangle, mininter, mincover float64) map[int]int {
+	// Partition detections by whether they would be ignored.
+	var out, in []int
+	for i := 0; i < dets.Len(); i++ {
+		if anyCovers(ignore, dets.At(i).Rect, mincover) {
+			in = append(in, i)
+		} else {
+			out = append(out, i)
+		}
+	}
+	match := make(map[int]int)
+	used := make(map[int]bool)
+	for _, subset := range [][]int{out, in} {
+		// Construct list of remaining references.
+		var rest []int
+		for j := range refs {
+			if !used[j] {
+				rest = append(rest, j)
+			}
+		}
+		restRefs := make([]image.Rectangle, len(rest))
+		for p, j := range rest {
+			restRefs[p] = refs[j]
+		}
+		sub := make(DetSlice, len(subset))
+		for q, i := range subset {
+			sub[q] = dets.At(i)
+		}
+		for q, p := range Match(sub, restRefs, mininter) {
+			match[subset[q]] = rest[p]
+			used[rest[p]] = true
+		}
+	}
+	return match
+}
+
+// Solves the square assignment problem.
+// Returns the column assigned to each row which minimizes the total cost.
+//
+// Uses the O(n^3) shortest augmenting path algorithm with potentials.
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+	// Indices are offset by one: row and column zero are sentinels.
+	var (
+		u   = make([]float64, n+1)
+		v   = make([]float64, n+1)
+		row = make([]int, n+1) // Row assigned to each column.
+		way = make([]int, n+1)
+	)
+	for i := 1; i <= n; i++ {
+		row[0] = i
+		j0 := 0
+		minv := make([]float64, n+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		used := make([]bool, n+1)
+		for {
+			used[j0] = true
+			i0 := row[j0]
+			delta, j1 := math.Inf(1), 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[row[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if row[j0] == 0 {
+				break
+			}
+		}
+		// Augment along the path.
+		for j0 != 0 {
+			j1 := way[j0]
+			row[j0] = row[j1]
+			j0 = j1
+		}
+	}
+	assign := make([]int, n)
+	for j := 1; j <= n; j++ {
+		if row[j] != 0 {
+			assign[row[j]-1] = j - 1
+		}
+	}
+	return assign
+}
@@ -0,0 +1,141 @@
+package detect_test
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+func TestValidateMatch_optimal(t *testing.T) {
+	refs := []image.Rectangle{
+		image.Rect(0, 0, 100, 100),
+		image.Rect(40, 0, 140, 100),
+	}
+	dets := []detect.Det{
+		// IOU 0.74 with first, 0.6 with second.
+		{10, image.Rect(15, 0, 115, 100)},
+		// IOU 0.67 with first.
+		{9, image.Rect(-20, 0, 80, 100)},
+	}
+	greedy := detect.ValidateMatch(dets, refs, nil, 0.5, 0.5, detect.MatchGreedy)
+	if len(greedy.Misses) != 1 {
+		t.Errorf("greedy: want 1 miss, got %d", len(greedy.Misses))
+	}
+	opt := detect.ValidateMatch(dets, refs, nil, 0.5, 0.5, detect.MatchOptimal)
+	if len(opt.Misses) != 0 {
+		t.Errorf("optimal: want 0 misses, got %d", len(opt.Misses))
+	}
+	if !opt.Dets[0].True || opt.Dets[0].Ref != refs[1] {
+		t.Errorf("optimal: first detection: want match to %v, got %+v", refs[1], opt.Dets[0].Val)
+	}
+	if !opt.Dets[1].True || opt.Dets[1].Ref != refs[0] {
+		t.Errorf("optimal: second detection: want match to %v, got %+v", refs[0], opt.Dets[1].Val)
+	}
+}
+
+func TestValidateMatch_preferRefs(t *testing.T) {
+	refs := []image.Rectangle{image.Rect(0, 0, 100, 100)}
+	ignore := []image.Rectangle{image.Rect(50, 0, 200, 100)}
+	dets := []detect.Det{
+		// Matches reference, mostly covered by ignore region.
+		{10, image.Rect(20, 0, 120, 100)},
+		// Matches reference, mostly outside ignore region.
+		{9, image.Rect(-10, 0, 90, 100)},
+	}
+	greedy := detect.ValidateMatch(dets, refs, ignore, 0.5, 0.5, detect.MatchGreedy)
+	if !greedy.Dets[0].True || greedy.Dets[1].True || greedy.Dets[1].Ignore {
+		t.Errorf("greedy: want true then false, got %+v, %+v", greedy.Dets[0].Val, greedy.Dets[1].Val)
+	}
+	pref := detect.ValidateMatch(dets, refs, ignore, 0.5, 0.5, detect.MatchPreferRefs)
+	if !pref.Dets[0].Ignore || !pref.Dets[1].True {
+		t.Errorf("prefer refs: want ignored then true, got %+v, %+v", pref.Dets[0].Val, pref.Dets[1].Val)
+	}
+	if len(pref.Misses) != 0 {
+		t.Errorf("prefer refs: want 0 misses, got %d", len(pref.Misses))
+	}
+}
+
+func TestMatchOptimalIOU(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	// Rectangles are placed in separate clusters
+	// so that the pairs form several components.
+	randRect := func() image.Rectangle {
+		x, y := 200*r.Intn(3)+r.Intn(60), r.Intn(60)
+		return image.Rect(x, y, x+40+r.Intn(20), y+40+r.Intn(20))
+	}
+	for trial := 0; trial < 100; trial++ {
+		dets := make([]detect.Det, r.Intn(8))
+		for i := range dets {
+			dets[i] = detect.Det{float64(-i), randRect()}
+		}
+		refs := make([]image.Rectangle, r.Intn(6))
+		for j := range refs {
+			refs[j] = randRect()
+		}
+		const min = 0.3
+		m := detect.MatchOptimalIOU(detect.DetSlice(dets), refs, min)
+		used := make(map[int]bool)
+		var total float64
+		for i, j := range m {
+			if used[j] {
+				t.Fatalf("reference %d matched twice", j)
+			}
+			used[j] = true
+			inter := detect.IOU(dets[i].Rect, refs[j])
+			if inter < min {
+				t.Fatalf("insufficient overlap: %g", inter)
+			}
+			total += inter
+		}
+		want := bruteMatch(dets, refs, min, 0, make(map[int]bool))
+		if math.Abs(total-want) > 1e-9 {
+			t.Errorf("trial %d: want total %.6g, got %.6g", trial, want, total)
+		}
+	}
+}
+
+// Returns the maximum total IOU of dets[i:].
+func bruteMatch(dets []detect.Det, refs []image.Rectangle, min float64, i int, used map[int]bool) float64 {
+	if i == len(dets) {
+		return 0
+	}
+	// Leave detection unmatched.
+	best := bruteMatch(dets, refs, min, i+1, used)
+	for j := range refs {
+		if used[j] {
+			continue
+		}
+		inter := detect.IOU(dets[i].Rect, refs[j])
+		if inter < min {
+			continue
+		}
+		used[j] = true
+		best = math.Max(best, inter+bruteMatch(dets, refs, min, i+1, used))
+		used[j] = false
+	}
+	return best
+}
+
+func BenchmarkMatchOptimalIOU_1000(b *testing.B) {
+	benchmarkMatchOptimalIOU(b, 1000)
+}
+
+func BenchmarkMatchOptimalIOU_10000(b *testing.B) {
+	benchmarkMatchOptimalIOU(b, 10000)
+}
+
+func benchmarkMatchOptimalIOU(b *testing.B, n int) {
+	r := rand.New(rand.NewSource(1))
+	dets := randDets(n, r)
+	refs := make([]image.Rectangle, 20)
+	for j, det := range randDets(len(refs), r) {
+		refs[j] = det.Rect
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		detect.MatchOptimalIOU(detect.DetSlice(dets), refs, 0.5)
+	}
+}
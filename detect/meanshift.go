@@ -0,0 +1,181 @@
+package detect
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// MeanShiftFilter specifies parameters to MeanShift.
+//
+// Each detection is a point (x, y, s) where (x, y) is the center
+// and s is the log of the height.
+// The bandwidth of a detection in x and y is proportional to its height,
+// the bandwidth in s is constant.
+type MeanShiftFilter struct {
+	// Bandwidth in x and y as a fraction of the height of each detection.
+	SigmaX, SigmaY float64
+	// Bandwidth in log scale.
+	SigmaScale float64
+	// Detections are weighted by their score minus Thresh.
+	// Detections whose score does not exceed Thresh are discarded.
+	Thresh float64
+	// Mean-shift stops when the step is less than Tol bandwidths
+	// or after MaxIter iterations.
+	Tol     float64
+	MaxIter int
+}
+
+// Suppress calls MeanShift.
+func (f MeanShiftFilter) Suppress(dets []Det) []Det {
+	return MeanShift(dets, f)
+}
+
+// MeanShift fuses detections by finding the modes
+// of a kernel density estimate in (x, y, log scale).
+// The detections do not need to be sorted.
+//
+// Mean-shift is started from every detection.
+// Modes which are within one bandwidth of a mode with greater density are merged.
+// The score of each mode is the (unnormalized) kernel density
+// 	sum_i |H_i|^(-1/2) t_i exp(-D^2(y, y_i, H_i) / 2),
+// where t_i is the weight of detection i and D is the Mahalanobis distance.
+// The aspect ratio of each mode is the weighted mean of the detections'.
+// Returns the modes ordered (descending) by score.
+//
+// Dalal, "Finding People in Images and Videos", PhD thesis, 2006.
+func MeanShift(dets []Det, opts MeanShiftFilter) []Det {
+	var pts []msPoint
+	for _, det := range dets {
+		if !(det.Score > opts.Thresh) || det.Rect.Empty() {
+			continue
+		}
+		x, y := centroid(det.Rect)
+		h := float64(det.Rect.Dy())
+		p := msPoint{
+			Pos:    [3]float64{x, y, math.Log(h)},
+			Var:    [3]float64{sqr(opts.SigmaX * h), sqr(opts.SigmaY * h), sqr(opts.SigmaScale)},
+			Aspect: float64(det.Rect.Dx()) / h,
+		}
+		p.Weight = (det.Score - opts.Thresh) / math.Sqrt(p.Var[0]*p.Var[1]*p.Var[2])
+		pts = append(pts, p)
+	}
+
+	var modes []msMode
+	for _, p := range pts {
+		y := p.Pos
+		for iter := 0; iter < opts.MaxIter; iter++ {
+			next := msStep(pts, y)
+			// Measure step relative to bandwidth at current scale.
+			h := math.Exp(y[2])
+			step := math.Max(
+				math.Max(math.Abs(next[0]-y[0])/(opts.SigmaX*h), math.Abs(next[1]-y[1])/(opts.SigmaY*h)),
+				math.Abs(next[2]-y[2])/opts.SigmaScale,
+			)
+			y = next
+			if step < opts.Tol {
+				break
+			}
+		}
+		density, aspect := msDensity(pts, y)
+		modes = append(modes, msMode{y, density, aspect})
+	}
+
+	// Merge modes in order of density.
+	sort.Sort(msModesByDensityDesc(modes))
+	var uniq []msMode
+	for _, m := range modes {
+		var dup bool
+		for _, u := range uniq {
+			h := math.Exp(u.Pos[2])
+			d := sqr((m.Pos[0]-u.Pos[0])/(opts.SigmaX*h)) +
+				sqr((m.Pos[1]-u.Pos[1])/(opts.SigmaY*h)) +
+				sqr((m.Pos[2]-u.Pos[2])/opts.SigmaScale)
+			if d < 1 {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			uniq = append(uniq, m)
+		}
+	}
+
+	fused := make([]Det, len(uniq))
+	for i, m := range uniq {
+		h := math.Exp(m.Pos[2])
+		w := m.Aspect * h
+		x, y := m.Pos[0], m.Pos[1]
+		rect := image.Rect(round(x-w/2), round(y-h/2), round(x+w/2), round(y+h/2))
+		fused[i] = Det{m.Density, rect}
+	}
+	return fused
+}
+
+type msPoint struct {
+	Pos [3]float64
+	// Diagonal of bandwidth matrix.
+	Var [3]float64
+	// Score weight divided by sqrt of determinant of bandwidth.
+	Weight float64
+	Aspect float64
+}
+
+type msMode struct {
+	Pos     [3]float64
+	Density float64
+	Aspect  float64
+}
+
+type msModesByDensityDesc []msMode
+
+func (s msModesByDensityDesc) Len() int           { return len(s) }
+func (s msModesByDensityDesc) Less(i, j int) bool { return s[i].Density > s[j].Density }
+func (s msModesByDensityDesc) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Kernel weight of point p at y.
+func (p msPoint) kernel(y [3]float64) float64 {
+	var d float64
+	for k := range y {
+		d += sqr(y[k]-p.Pos[k]) / p.Var[k]
+	}
+	return p.Weight * math.Exp(-d/2)
+}
+
+// Computes the next position
+// 	y = (sum_i w_i H_i^-1)^-1 sum_i w_i H_i^-1 y_i.
+// Returns the current position if all weights are zero.
+func msStep(pts []msPoint, y [3]float64) [3]float64 {
+	var num, den [3]float64
+	for _, p := range pts {
+		w := p.kernel(y)
+		for k := range y {
+			num[k] += w * p.Pos[k] / p.Var[k]
+			den[k] += w / p.Var[k]
+		}
+	}
+	var next [3]float64
+	for k := range y {
+		if !(den[k] > 0) {
+			return y
+		}
+		next[k] = num[k] / den[k]
+	}
+	return next
+}
+
+// Returns the kernel density and weighted mean aspect ratio at y.
+func msDensity(pts []msPoint, y [3]float64) (density, aspect float64) {
+	var sum float64
+	for _, p := range pts {
+		w := p.kernel(y)
+		density += w
+		sum += w * p.Aspect
+	}
+	if density > 0 {
+		aspect = sum / density
+	}
+	return density, aspect
+}
+
+func sqr(x float64) float64 { return x * x }
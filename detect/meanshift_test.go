@@ -0,0 +1,56 @@
+package detect_test
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+func TestMeanShift(t *testing.T) {
+	opts := detect.MeanShiftFilter{
+		SigmaX:     0.1,
+		SigmaY:     0.1,
+		SigmaScale: math.Log(1.3),
+		Tol:        1e-4,
+		MaxIter:    100,
+	}
+	r := rand.New(rand.NewSource(1))
+	big := image.Rect(100, 100, 150, 200)
+	small := image.Rect(400, 50, 420, 90)
+	var dets []detect.Det
+	for i := 0; i < 8; i++ {
+		d := image.Pt(r.Intn(5)-2, r.Intn(5)-2)
+		dets = append(dets, detect.Det{1 + r.Float64(), big.Add(d)})
+	}
+	for i := 0; i < 3; i++ {
+		d := image.Pt(r.Intn(3)-1, r.Intn(3)-1)
+		dets = append(dets, detect.Det{1 + r.Float64(), small.Add(d)})
+	}
+	// Discarded by threshold.
+	dets = append(dets, detect.Det{-1, image.Rect(600, 0, 650, 100)})
+
+	modes := detect.MeanShift(dets, opts)
+	if len(modes) != 2 {
+		t.Fatalf("want 2 modes, got %d: %v", len(modes), modes)
+	}
+	// Density is greater for smaller detections because the bandwidth is smaller.
+	for i, want := range []image.Rectangle{small, big} {
+		if detect.IOU(modes[i].Rect, want) < 0.8 {
+			t.Errorf("mode %d: want near %v, got %v", i, want, modes[i].Rect)
+		}
+	}
+	if !(modes[0].Score > modes[1].Score) {
+		t.Errorf("modes not ordered by density: %v", modes)
+	}
+
+	// Density of a single detection is its weight.
+	single := detect.MeanShift([]detect.Det{{2, big}}, opts)
+	h := float64(big.Dy())
+	want := 2 / math.Sqrt(opts.SigmaX*h*opts.SigmaX*h*opts.SigmaY*h*opts.SigmaY*h*opts.SigmaScale*opts.SigmaScale)
+	if len(single) != 1 || single[0].Rect != big || math.Abs(single[0].Score-want) > 1e-9*want {
+		t.Errorf("single: want %v, got %v", detect.Det{want, big}, single)
+	}
+}
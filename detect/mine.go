@@ -0,0 +1,283 @@
+package detect
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/jvlmdr/go-cv/featpyr"
+	"github.com/jvlmdr/go-cv/imgpyr"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/slide"
+)
+
+// MineOpts specifies the parameters to MineImage() and Mine().
+//
+// The pyramid is searched as in MultiScale.
+// Windows scoring below -Margin are ignored,
+// as are windows which do not satisfy DetFilter.
+// Non-max suppression is performed using SupprFilter,
+// therefore MaxNum is the maximum number of windows per image.
+type MineOpts struct {
+	MultiScaleOpts
+	Margin float64
+}
+
+// NegWindow is a window from a negative image.
+type NegWindow struct {
+	// Index of the image in the list.
+	Image int
+	// Level of the pyramid and position of the window in the feature image.
+	imgpyr.Point
+	// Rectangle in the image.
+	Rect image.Rectangle
+	// Score when the window was last evaluated.
+	Score float64
+	// Feature window.
+	Feat *rimg64.Multi
+}
+
+type negKey struct {
+	Image int
+	imgpyr.Point
+}
+
+func (w *NegWindow) key() negKey { return negKey{w.Image, w.Point} }
+
+// MineImage finds the hardest windows in a negative image.
+// The index is recorded in each window.
+// Returns windows sorted by descending score.
+func MineImage(index int, im image.Image, scorer slide.Scorer, shape PadRect, opts MineOpts) ([]*NegWindow, error) {
+	size := scorer.Size()
+	minScore := math.Max(opts.DetFilter.MinScore, -opts.Margin)
+	scales := imgpyr.Scales(im.Bounds().Size(), size, opts.MaxScale, opts.PyrStep).Elems()
+	ims := imgpyr.NewGenerator(im, scales, opts.Interp)
+	pyr := featpyr.NewGenerator(ims, opts.Transform, opts.Pad)
+	var wins []*NegWindow
+	l, err := pyr.First()
+	if err != nil {
+		return nil, err
+	}
+	for l != nil {
+		pts, err := Points(l.Feat, scorer, opts.DetFilter.LocalMax, minScore)
+		if err != nil {
+			return nil, err
+		}
+		for _, pt := range pts {
+			wins = append(wins, &NegWindow{
+				Image: index,
+				Point: imgpyr.Point{l.Image.Index, pt.Point},
+				Rect:  pyr.ToImageRect(l.Image.Index, pt.Point, shape.Int),
+				Score: pt.Score,
+				Feat:  l.Feat.SubImage(image.Rectangle{pt.Point, pt.Point.Add(size)}),
+			})
+		}
+		l, err = pyr.Next(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Sort(negWindowsByScoreDesc(wins))
+	inds := SuppressIndex(negWindowList(wins), opts.SupprFilter.MaxNum, opts.SupprFilter.Overlap)
+	subset := make([]*NegWindow, len(inds))
+	for i, ind := range inds {
+		subset[i] = wins[ind]
+	}
+	return subset, nil
+}
+
+type negWindowsByScoreDesc []*NegWindow
+
+func (s negWindowsByScoreDesc) Len() int           { return len(s) }
+func (s negWindowsByScoreDesc) Less(i, j int) bool { return s[i].Score > s[j].Score }
+func (s negWindowsByScoreDesc) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Satisfies DetList.
+type negWindowList []*NegWindow
+
+func (s negWindowList) Len() int     { return len(s) }
+func (s negWindowList) At(i int) Det { return Det{s[i].Score, s[i].Rect} }
+
+// NegCache is a set of at most Size negative windows.
+// A cache which was not created by NewNegCache,
+// for example one which was decoded, may be used
+// provided that Windows does not contain the same window twice.
+type NegCache struct {
+	Size    int
+	Windows []*NegWindow
+	index   map[negKey]int
+}
+
+// NewNegCache creates an empty cache.
+// Panics if size is not positive.
+func NewNegCache(size int) *NegCache {
+	if size <= 0 {
+		panic(fmt.Sprintf("cache size must be positive: %d", size))
+	}
+	return &NegCache{Size: size, index: make(map[negKey]int)}
+}
+
+// Feats returns the feature window of every negative in the cache.
+func (c *NegCache) Feats() []*rimg64.Multi {
+	x := make([]*rimg64.Multi, len(c.Windows))
+	for i, w := range c.Windows {
+		x[i] = w.Feat
+	}
+	return x
+}
+
+// Add inserts a window into the cache.
+// A window which is already in the cache is updated.
+// If the cache is full, then the window with the lowest score is replaced,
+// unless its score is greater than that of the new window.
+// Returns whether the window was added, updated or rejected.
+func (c *NegCache) Add(w *NegWindow) CacheOutcome {
+	c.initIndex()
+	if i, ok := c.index[w.key()]; ok {
+		c.Windows[i] = w
+		return CacheUpdated
+	}
+	if len(c.Windows) < c.Size {
+		c.index[w.key()] = len(c.Windows)
+		c.Windows = append(c.Windows, w)
+		return CacheAdded
+	}
+	// Find easiest window.
+	easy := 0
+	for i, v := range c.Windows {
+		if v.Score < c.Windows[easy].Score {
+			easy = i
+		}
+	}
+	if c.Windows[easy].Score > w.Score {
+		return CacheRejected
+	}
+	delete(c.index, c.Windows[easy].key())
+	c.Windows[easy] = w
+	c.index[w.key()] = easy
+	return CacheReplaced
+}
+
+// Builds the index from the windows if it does not exist.
+func (c *NegCache) initIndex() {
+	if c.index != nil {
+		return
+	}
+	c.index = make(map[negKey]int, len(c.Windows))
+	for i, w := range c.Windows {
+		c.index[w.key()] = i
+	}
+}
+
+// CacheOutcome describes the result of adding a window to a cache.
+type CacheOutcome int
+
+const (
+	CacheAdded CacheOutcome = iota
+	CacheUpdated
+	CacheReplaced
+	CacheRejected
+)
+
+// Evict re-evaluates every window and removes those which score below min.
+// Returns the number of windows removed.
+func (c *NegCache) Evict(scorer slide.Scorer, min float64) (int, error) {
+	c.initIndex()
+	var n int
+	for _, w := range c.Windows {
+		score, err := scorer.Score(w.Feat)
+		if err != nil {
+			return 0, err
+		}
+		w.Score = score
+		if score < min {
+			delete(c.index, w.key())
+			continue
+		}
+		c.Windows[n] = w
+		c.index[w.key()] = n
+		n++
+	}
+	removed := len(c.Windows) - n
+	c.Windows = c.Windows[:n]
+	return removed, nil
+}
+
+// MineRound describes how the cache changed during one round of Mine().
+type MineRound struct {
+	// Number of windows found in the images.
+	Mined int
+	// Number of new windows added to the cache,
+	// including those which replaced an easier window.
+	Added int
+	// Number of windows which were already in the cache.
+	Updated int
+	// Number of windows which replaced an easier window.
+	Replaced int
+	// Number of windows discarded because the cache was full.
+	Rejected int
+	// Number of easy windows evicted after training.
+	Evicted int
+	// Size of the cache at the end of the round.
+	Size int
+}
+
+func (r MineRound) String() string {
+	return fmt.Sprintf("mined %d, added %d (replaced %d), updated %d, rejected %d, evicted %d, size %d",
+		r.Mined, r.Added, r.Replaced, r.Updated, r.Rejected, r.Evicted, r.Size)
+}
+
+// TrainFunc learns a scorer from the negative windows in the cache.
+type TrainFunc func(neg []*rimg64.Multi) (slide.Scorer, error)
+
+// Mine performs rounds of hard negative mining.
+//
+// Each round searches every image for windows scoring above -Margin,
+// adds them to the cache, calls train with the cache,
+// and then evicts windows which score below -Margin with the new scorer.
+// The final scorer and a description of each round are returned.
+// Mining stops early if a round finds no windows.
+func Mine(ims []image.Image, scorer slide.Scorer, train TrainFunc, cache *NegCache, rounds int, shape PadRect, opts MineOpts) (slide.Scorer, []MineRound, error) {
+	var report []MineRound
+	for iter := 0; iter < rounds; iter++ {
+		var r MineRound
+		for i, im := range ims {
+			wins, err := MineImage(i, im, scorer, shape, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			r.Mined += len(wins)
+			for _, w := range wins {
+				switch cache.Add(w) {
+				case CacheAdded:
+					r.Added++
+				case CacheUpdated:
+					r.Updated++
+				case CacheReplaced:
+					r.Added++
+					r.Replaced++
+				case CacheRejected:
+					r.Rejected++
+				}
+			}
+		}
+		if r.Mined == 0 {
+			r.Size = len(cache.Windows)
+			report = append(report, r)
+			break
+		}
+		next, err := train(cache.Feats())
+		if err != nil {
+			return nil, nil, err
+		}
+		scorer = next
+		r.Evicted, err = cache.Evict(scorer, -opts.Margin)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Size = len(cache.Windows)
+		report = append(report, r)
+	}
+	return scorer, report, nil
+}
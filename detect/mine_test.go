@@ -0,0 +1,179 @@
+package detect_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/feat"
+	"github.com/jvlmdr/go-cv/featset"
+	"github.com/jvlmdr/go-cv/imgpyr"
+	"github.com/jvlmdr/go-cv/imsamp"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/slide"
+	"github.com/nfnt/resize"
+)
+
+func noiseImage(size int, r *rand.Rand) image.Image {
+	im := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range im.Pix {
+		im.Pix[i] = uint8(r.Intn(256))
+	}
+	return im
+}
+
+func mineTestSetup() ([]image.Image, *slide.AffineScorer, detect.PadRect, detect.MineOpts) {
+	r := rand.New(rand.NewSource(1))
+	var ims []image.Image
+	for i := 0; i < 4; i++ {
+		ims = append(ims, noiseImage(32, r))
+	}
+	tmpl := rimg64.NewMulti(6, 6, 1)
+	for i := range tmpl.Elems {
+		tmpl.Elems[i] = r.NormFloat64()
+	}
+	scorer := &slide.AffineScorer{Tmpl: tmpl, Bias: 1}
+	shape := detect.PadRect{Size: image.Pt(6, 6), Int: image.Rect(1, 1, 5, 5)}
+	opts := detect.MineOpts{
+		MultiScaleOpts: detect.MultiScaleOpts{
+			MaxScale:  1,
+			PyrStep:   1.2,
+			Interp:    resize.Bilinear,
+			Transform: new(featset.Gray),
+			Pad:       feat.Pad{feat.UniformMargin(0), imsamp.Continue},
+			DetFilter: detect.DetFilter{LocalMax: true, MinScore: math.Inf(-1)},
+			SupprFilter: detect.SupprFilter{
+				MaxNum:  10,
+				Overlap: func(a, b image.Rectangle) bool { return detect.IOU(a, b) > 0.5 },
+			},
+		},
+		Margin: 1,
+	}
+	return ims, scorer, shape, opts
+}
+
+func TestMineImage(t *testing.T) {
+	ims, scorer, shape, opts := mineTestSetup()
+	wins, err := detect.MineImage(3, ims[0], scorer, shape, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wins) == 0 {
+		t.Fatal("no windows")
+	}
+	if len(wins) > opts.SupprFilter.MaxNum {
+		t.Errorf("too many windows: max %d, got %d", opts.SupprFilter.MaxNum, len(wins))
+	}
+	for i, w := range wins {
+		if w.Image != 3 {
+			t.Errorf("window %d: wrong image index: %d", i, w.Image)
+		}
+		if i > 0 && w.Score > wins[i-1].Score {
+			t.Errorf("window %d: not sorted", i)
+		}
+		if w.Score < -opts.Margin {
+			t.Errorf("window %d: score %g below margin", i, w.Score)
+		}
+		// Feature window must reproduce the score.
+		score, err := scorer.Score(w.Feat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(score-w.Score) > 1e-9 {
+			t.Errorf("window %d: score of features %g, detection %g", i, score, w.Score)
+		}
+	}
+}
+
+func TestMine(t *testing.T) {
+	ims, scorer, shape, opts := mineTestSetup()
+	cache := detect.NewNegCache(25)
+	var calls int
+	// Each round makes every window easier.
+	train := func(neg []*rimg64.Multi) (slide.Scorer, error) {
+		calls++
+		if len(neg) == 0 || len(neg) > cache.Size {
+			t.Errorf("wrong number of negatives: %d", len(neg))
+		}
+		next := *scorer
+		next.Bias -= float64(calls)
+		return &next, nil
+	}
+	const rounds = 3
+	final, report, err := detect.Mine(ims, scorer, train, cache, rounds, shape, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) == 0 || len(report) > rounds {
+		t.Fatalf("wrong number of rounds: %d", len(report))
+	}
+	if final == nil {
+		t.Fatal("no scorer")
+	}
+	var size int
+	for i, r := range report {
+		t.Logf("round %d: %v", i, r)
+		if r.Added+r.Updated+r.Rejected != r.Mined {
+			t.Errorf("round %d: outcomes do not sum to mined: %v", i, r)
+		}
+		if want := size + r.Added - r.Replaced - r.Evicted; r.Size != want {
+			t.Errorf("round %d: size: want %d, got %d", i, want, r.Size)
+		}
+		if r.Size > cache.Size {
+			t.Errorf("round %d: size %d exceeds cache size %d", i, r.Size, cache.Size)
+		}
+		size = r.Size
+	}
+	if len(cache.Windows) != size {
+		t.Errorf("cache has %d windows, report says %d", len(cache.Windows), size)
+	}
+	for i, w := range cache.Windows {
+		if w.Score < -opts.Margin {
+			t.Errorf("window %d: easy window in cache: %g", i, w.Score)
+		}
+	}
+}
+
+func TestNegCache_decoded(t *testing.T) {
+	win := func(index, x int, score float64) *detect.NegWindow {
+		return &detect.NegWindow{
+			Image: index,
+			Point: imgpyr.Point{0, image.Pt(x, 0)},
+			Score: score,
+			Feat:  rimg64.NewMulti(1, 1, 1),
+		}
+	}
+	src := detect.NewNegCache(2)
+	src.Add(win(0, 0, 1))
+	src.Add(win(0, 1, 2))
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(src); err != nil {
+		t.Fatal(err)
+	}
+	var cache *detect.NegCache
+	if err := gob.NewDecoder(&buf).Decode(&cache); err != nil {
+		t.Fatal(err)
+	}
+	if got := cache.Add(win(0, 1, 3)); got != detect.CacheUpdated {
+		t.Errorf("existing window: want outcome %v, got %v", detect.CacheUpdated, got)
+	}
+	if got := cache.Add(win(1, 0, 4)); got != detect.CacheReplaced {
+		t.Errorf("new window: want outcome %v, got %v", detect.CacheReplaced, got)
+	}
+	if len(cache.Windows) != 2 {
+		t.Errorf("want 2 windows, got %d", len(cache.Windows))
+	}
+
+	// Literal with no windows.
+	lit := &detect.NegCache{Size: 1}
+	if got := lit.Add(win(0, 0, 1)); got != detect.CacheAdded {
+		t.Errorf("literal: want outcome %v, got %v", detect.CacheAdded, got)
+	}
+	if _, err := lit.Evict(&slide.AffineScorer{Tmpl: rimg64.NewMulti(1, 1, 1)}, 0); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,12 +1,16 @@
 package detect
 
 import (
+	"context"
 	"image"
+	"sync"
 	"time"
 
 	"github.com/jvlmdr/go-cv/feat"
 	"github.com/jvlmdr/go-cv/featpyr"
 	"github.com/jvlmdr/go-cv/imgpyr"
+	"github.com/jvlmdr/go-cv/metrics"
+	"github.com/jvlmdr/go-cv/rimg64"
 	"github.com/jvlmdr/go-cv/slide"
 	"github.com/nfnt/resize"
 )
@@ -20,51 +24,148 @@ type MultiScaleOpts struct {
 	feat.Pad
 	DetFilter
 	SupprFilter
+	// If not nil, used instead of SupprFilter.
+	// For example, SoftSupprFilter or FuseFilter.
+	Suppressor Suppressor
+	// If greater than one, up to Workers levels are evaluated concurrently.
+	// The image pyramid is still computed in order.
+	Workers int
+	// If not nil, called after the scorer has been evaluated at each level.
+	// Calls are not concurrent.
+	Progress featpyr.Progress
+	// If not nil, receives the time and size of each stage.
+	// The time of stages which run concurrently may sum
+	// to more than the elapsed time.
+	Metrics metrics.Recorder
 }
 
-type MultiScaleDuration struct{ Resize, Feat, Slide, Suppr time.Duration }
-
 // MultiScale searches an image at multiple scales and performs non-max suppression.
 //
 // At each level, the image is rescaled using Interp,
 // then padded using Pad before calling Transform.Apply().
 // The levels are geometrically spaced at intervals of PyrStep.
 // Detections are filtered using DetFilter and then non-max suppression
-// is performed using the OverlapFunc test.
-func MultiScale(im image.Image, scorer slide.Scorer, shape PadRect, opts MultiScaleOpts) ([]Det, MultiScaleDuration, error) {
+// is performed using the OverlapFunc test,
+// or using Suppressor if it is not nil.
+// If Workers is greater than one, the scorer and Transform
+// must be safe for concurrent use.
+// The result does not depend on the number of workers.
+func MultiScale(im image.Image, scorer slide.Scorer, shape PadRect, opts MultiScaleOpts) ([]Det, error) {
+	return MultiScaleContext(context.Background(), im, scorer, shape, opts)
+}
+
+// MultiScaleContext is like MultiScale but returns the error of the context
+// if it is done before every level has been evaluated.
+func MultiScaleContext(ctx context.Context, im image.Image, scorer slide.Scorer, shape PadRect, opts MultiScaleOpts) ([]Det, error) {
 	scales := imgpyr.Scales(im.Bounds().Size(), scorer.Size(), opts.MaxScale, opts.PyrStep).Elems()
 	ims := imgpyr.NewGenerator(im, scales, opts.Interp)
 	pyr := featpyr.NewGenerator(ims, opts.Transform, opts.Pad)
+	pyr.Metrics = opts.Metrics
+	num := len(pyr.Image.Scales)
+	c := newLevelPoints(num, scorer.Size(), opts.DetFilter)
+	var err error
+	if opts.Workers > 1 {
+		err = slideLevelsParallel(ctx, pyr, scorer, c.add, opts.Workers, opts.Progress, opts.Metrics)
+	} else {
+		err = slideLevels(ctx, pyr, scorer, c.add, opts.Progress, opts.Metrics)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pts, dur := c.points(pyr.ToLevel)
+	if opts.Metrics != nil {
+		opts.Metrics.Record(metrics.Event{Stage: metrics.Points, Level: -1, Dur: dur})
+		counts := make([]int, num)
+		for _, pt := range pts {
+			counts[pt.Level]++
+		}
+		for i, n := range counts {
+			opts.Metrics.Record(metrics.Event{Stage: metrics.Points, Level: i, Count: n})
+		}
+	}
+	// Convert to scored rectangles in the image.
 	var dets []Det
-	l, err := pyr.First()
+	for _, pt := range pts {
+		rect := pyr.ToImageRect(pt.Level, pt.Pos, shape.Int)
+		dets = append(dets, Det{pt.Score, rect})
+	}
+	t := time.Now()
+	Sort(dets)
+	if opts.Suppressor != nil {
+		dets = opts.Suppressor.Suppress(dets)
+	} else {
+		dets = Suppress(dets, opts.SupprFilter.MaxNum, opts.SupprFilter.Overlap)
+	}
+	metrics.Record(opts.Metrics, metrics.Event{
+		Stage: metrics.Suppr,
+		Level: -1,
+		Dur:   time.Since(t),
+		Count: len(dets),
+	})
+	return dets, nil
+}
+
+// Evaluates the scorer at every level in order
+// and passes the response at each level to visit.
+func slideLevels(ctx context.Context, pyr *featpyr.Generator, scorer slide.Scorer, visit func(int, *rimg64.Image), progress featpyr.Progress, rec metrics.Recorder) error {
+	l, err := pyr.FirstContext(ctx)
 	if err != nil {
-		return nil, MultiScaleDuration{}, err
+		return err
 	}
-	var dur MultiScaleDuration
 	for l != nil {
-		t := time.Now()
-		pts, err := Points(l.Feat, scorer, opts.DetFilter.LocalMax, opts.DetFilter.MinScore)
+		resp, err := slideLevel(ctx, l, scorer, rec)
 		if err != nil {
-			return nil, MultiScaleDuration{}, err
+			return err
 		}
-		dur.Slide += time.Since(t)
-		// Convert to scored rectangles in the image.
-		for _, pt := range pts {
-			rect := pyr.ToImageRect(l.Image.Index, pt.Point, shape.Int)
-			dets = append(dets, Det{pt.Score, rect})
+		visit(l.Image.Index, resp)
+		if progress != nil {
+			progress(l.Image.Index+1, len(pyr.Image.Scales))
 		}
-		l, err = pyr.Next(l)
+		l, err = pyr.NextContext(ctx, l)
 		if err != nil {
-			return nil, MultiScaleDuration{}, err
+			return err
 		}
 	}
-	dur.Resize = pyr.DurResize
-	dur.Feat = pyr.DurFeat
+	return nil
+}
+
+// Evaluates the scorer at every level using up to n workers
+// and passes the response at each level to visit.
+// Calls to visit are concurrent but for distinct levels.
+func slideLevelsParallel(ctx context.Context, pyr *featpyr.Generator, scorer slide.Scorer, visit func(int, *rimg64.Image), n int, progress featpyr.Progress, rec metrics.Recorder) error {
+	var (
+		// Guards the number of levels done.
+		mu   sync.Mutex
+		done int
+	)
+	return pyr.ForEach(ctx, n, func(l *featpyr.Level) error {
+		resp, err := slideLevel(ctx, l, scorer, rec)
+		if err != nil {
+			return err
+		}
+		visit(l.Image.Index, resp)
+		if progress != nil {
+			mu.Lock()
+			done++
+			progress(done, len(pyr.Image.Scales))
+			mu.Unlock()
+		}
+		return nil
+	})
+}
+
+func slideLevel(ctx context.Context, l *featpyr.Level, scorer slide.Scorer, rec metrics.Recorder) (*rimg64.Image, error) {
 	t := time.Now()
-	Sort(dets)
-	dets = Suppress(dets, opts.SupprFilter.MaxNum, opts.SupprFilter.Overlap)
-	dur.Suppr = time.Since(t)
-	return dets, dur, nil
+	resp, err := slide.ScoreContext(ctx, l.Feat, scorer)
+	if err != nil {
+		return nil, err
+	}
+	e := metrics.Event{Stage: metrics.Slide, Level: l.Image.Index, Dur: time.Since(t)}
+	if resp != nil {
+		e.Size = resp.Size()
+	}
+	metrics.Record(rec, e)
+	return resp, nil
 }
 
 // Pyramid performs detection and non-max suppression.
@@ -72,7 +173,7 @@ func MultiScale(im image.Image, scorer slide.Scorer, shape PadRect, opts MultiSc
 // Windows are specified as rectangles in the original pixel image.
 func Pyramid(pyr *featpyr.Pyramid, scorer slide.Scorer, shape PadRect, detopts DetFilter, suppropts SupprFilter) ([]Det, error) {
 	// Get detections as top-left corners at some level.
-	featdets, err := detectPyrPoints(pyr, scorer, detopts.LocalMax, detopts.MinScore)
+	featdets, err := detectPyrPoints(pyr, scorer, detopts)
 	if err != nil {
 		return nil, err
 	}
@@ -95,23 +196,19 @@ type pyrDetPos struct {
 
 // Returns scored windows in image.
 // Windows are represented by the position of their top-left corner in the feature pyramid.
-func detectPyrPoints(pyr *featpyr.Pyramid, scorer slide.Scorer, localmax bool, minscore float64) ([]pyrDetPos, error) {
-	var dets []pyrDetPos
+func detectPyrPoints(pyr *featpyr.Pyramid, scorer slide.Scorer, opts DetFilter) ([]pyrDetPos, error) {
 	size := scorer.Size()
-	for level, im := range pyr.Feats {
+	c := newLevelPoints(len(pyr.Feats), size, opts)
+	for i, im := range pyr.Feats {
 		if im.Width < size.X || im.Height < size.Y {
 			break
 		}
-		// Get points from each level.
-		imdets, err := Points(im, scorer, localmax, minscore)
+		resp, err := slide.Score(im, scorer)
 		if err != nil {
 			return nil, err
 		}
-		// Append level to each point.
-		for _, imdet := range imdets {
-			pyrpt := imgpyr.Point{level, imdet.Point}
-			dets = append(dets, pyrDetPos{imdet.Score, pyrpt})
-		}
+		c.add(i, resp)
 	}
-	return dets, nil
+	pts, _ := c.points(pyr.ToLevel)
+	return pts, nil
 }
@@ -0,0 +1,113 @@
+package detect_test
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/metrics"
+)
+
+func TestMultiScale_workers(t *testing.T) {
+	_, scorer, shape, mineOpts := mineTestSetup()
+	im := noiseImage(64, rand.New(rand.NewSource(3)))
+	opts := mineOpts.MultiScaleOpts
+
+	opts.Workers = 0
+	want, err := detect.MultiScale(im, scorer, shape, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) == 0 {
+		t.Fatal("no detections")
+	}
+	for _, n := range []int{2, 3, 8, 100} {
+		opts.Workers = n
+		got, err := detect.MultiScale(im, scorer, shape, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("workers %d: different detections to sequential", n)
+		}
+	}
+}
+
+func TestMultiScaleContext(t *testing.T) {
+	_, scorer, shape, mineOpts := mineTestSetup()
+	im := noiseImage(64, rand.New(rand.NewSource(3)))
+	opts := mineOpts.MultiScaleOpts
+
+	for _, workers := range []int{0, 4} {
+		opts.Workers = workers
+		// Progress must count every level once.
+		var calls, last int
+		opts.Progress = func(done, total int) {
+			calls++
+			if done != last+1 || done > total {
+				t.Errorf("workers %d: progress %d of %d after %d", workers, done, total, last)
+			}
+			last = done
+		}
+		if _, err := detect.MultiScaleContext(context.Background(), im, scorer, shape, opts); err != nil {
+			t.Fatal(err)
+		}
+		if calls == 0 {
+			t.Errorf("workers %d: progress not reported", workers)
+		}
+
+		// Cancel after the first level.
+		ctx, cancel := context.WithCancel(context.Background())
+		opts.Progress = func(done, total int) { cancel() }
+		_, err := detect.MultiScaleContext(ctx, im, scorer, shape, opts)
+		if err != context.Canceled {
+			t.Errorf("workers %d: want %v, got %v", workers, context.Canceled, err)
+		}
+		cancel()
+	}
+}
+
+func TestMultiScale_metrics(t *testing.T) {
+	_, scorer, shape, mineOpts := mineTestSetup()
+	im := noiseImage(64, rand.New(rand.NewSource(3)))
+	opts := mineOpts.MultiScaleOpts
+
+	for _, workers := range []int{0, 4} {
+		opts.Workers = workers
+		var c metrics.Collector
+		opts.Metrics = &c
+		dets, err := detect.MultiScale(im, scorer, shape, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Every level must report each stage once.
+		levels := make(map[string]map[int]bool)
+		for _, e := range c.Events() {
+			if levels[e.Stage] == nil {
+				levels[e.Stage] = make(map[int]bool)
+			}
+			if levels[e.Stage][e.Level] {
+				t.Errorf("workers %d: stage %s, level %d: reported twice", workers, e.Stage, e.Level)
+			}
+			levels[e.Stage][e.Level] = true
+		}
+		num := len(levels[metrics.Slide])
+		if num == 0 {
+			t.Fatalf("workers %d: no levels", workers)
+		}
+		for _, stage := range []string{metrics.Resize, metrics.Feat} {
+			if len(levels[stage]) != num {
+				t.Errorf("workers %d: stage %s: want %d levels, got %d", workers, stage, num, len(levels[stage]))
+			}
+		}
+		totals := metrics.Totals(c.Events())
+		if totals[metrics.Suppr].Count != len(dets) {
+			t.Errorf("workers %d: want %d detections, got %d", workers, len(dets), totals[metrics.Suppr].Count)
+		}
+		if totals[metrics.Points].Count < len(dets) {
+			t.Errorf("workers %d: fewer candidates (%d) than detections (%d)", workers, totals[metrics.Points].Count, len(dets))
+		}
+	}
+}
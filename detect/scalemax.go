@@ -0,0 +1,113 @@
+package detect
+
+import (
+	"image"
+	"time"
+
+	"github.com/jvlmdr/go-cv/imgpyr"
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+// Maps a position in the feature image of one level to another level.
+type toLevelFunc func(from, to int, x, y float64) (float64, float64)
+
+// Finds the scored positions in the response at every level of a pyramid.
+// The responses are indexed by level and may be nil.
+// The size of the window is used to map its center between levels.
+func pyrRespPoints(resps []*rimg64.Image, size image.Point, opts DetFilter, toLevel toLevelFunc) []pyrDetPos {
+	var dets []pyrDetPos
+	for level, resp := range resps {
+		for _, pt := range RespPoints(resp, opts.LocalMax, opts.MinScore) {
+			if opts.ScaleMax && notScaleMax(resps, level, pt, size, opts.ScaleRadius, toLevel) {
+				continue
+			}
+			dets = append(dets, pyrDetPos{pt.Score, imgpyr.Point{level, pt.Point}})
+		}
+	}
+	return dets
+}
+
+// Collects the scored positions at every level of a pyramid
+// as the responses are computed.
+// The responses are only kept if they are needed
+// to test for maxima over scale,
+// otherwise the points are extracted from each level immediately.
+// Levels may be added concurrently provided that they are distinct.
+type levelPoints struct {
+	size  image.Point
+	opts  DetFilter
+	resps []*rimg64.Image
+	pts   [][]DetPos
+	durs  []time.Duration
+}
+
+func newLevelPoints(num int, size image.Point, opts DetFilter) *levelPoints {
+	c := &levelPoints{size: size, opts: opts}
+	if opts.ScaleMax {
+		c.resps = make([]*rimg64.Image, num)
+	} else {
+		c.pts = make([][]DetPos, num)
+		c.durs = make([]time.Duration, num)
+	}
+	return c
+}
+
+// Adds the response at one level.
+func (c *levelPoints) add(level int, resp *rimg64.Image) {
+	if c.opts.ScaleMax {
+		c.resps[level] = resp
+		return
+	}
+	t := time.Now()
+	c.pts[level] = RespPoints(resp, c.opts.LocalMax, c.opts.MinScore)
+	c.durs[level] = time.Since(t)
+}
+
+// Returns the points at every level in order
+// and the total time taken to find them.
+func (c *levelPoints) points(toLevel toLevelFunc) ([]pyrDetPos, time.Duration) {
+	if c.opts.ScaleMax {
+		t := time.Now()
+		dets := pyrRespPoints(c.resps, c.size, c.opts, toLevel)
+		return dets, time.Since(t)
+	}
+	var (
+		dets []pyrDetPos
+		dur  time.Duration
+	)
+	for level, pts := range c.pts {
+		for _, pt := range pts {
+			dets = append(dets, pyrDetPos{pt.Score, imgpyr.Point{level, pt.Point}})
+		}
+		dur += c.durs[level]
+	}
+	return dets, dur
+}
+
+// Tests whether a point is a local maximum
+// over position and scale.
+func notScaleMax(resps []*rimg64.Image, level int, pt DetPos, size image.Point, radius int, toLevel toLevelFunc) bool {
+	// Center of window in feature image.
+	x := float64(pt.X) + float64(size.X)/2
+	y := float64(pt.Y) + float64(size.Y)/2
+	for other := level - 1; other <= level+1; other++ {
+		if other < 0 || other >= len(resps) || resps[other] == nil {
+			continue
+		}
+		r := resps[other]
+		u, v := pt.X, pt.Y
+		if other != level {
+			xo, yo := toLevel(level, other, x, y)
+			u = round(xo - float64(size.X)/2)
+			v = round(yo - float64(size.Y)/2)
+		}
+		for i := max(u-radius, 0); i <= u+radius && i < r.Width; i++ {
+			for j := max(v-radius, 0); j <= v+radius && j < r.Height; j++ {
+				if r.At(i, j) > pt.Score {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
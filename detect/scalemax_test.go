@@ -0,0 +1,45 @@
+package detect_test
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+func TestMultiScale_scaleMax(t *testing.T) {
+	_, scorer, shape, mineOpts := mineTestSetup()
+	im := noiseImage(48, rand.New(rand.NewSource(2)))
+	opts := mineOpts.MultiScaleOpts
+	// Keep every detection.
+	opts.SupprFilter = detect.SupprFilter{Overlap: func(a, b image.Rectangle) bool { return false }}
+
+	all, err := detect.MultiScale(im, scorer, shape, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.DetFilter.ScaleMax = true
+	opts.DetFilter.ScaleRadius = 1
+	sub, err := detect.MultiScale(im, scorer, shape, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sub) == 0 || len(sub) >= len(all) {
+		t.Fatalf("want fewer than %d detections, got %d", len(all), len(sub))
+	}
+	// Best detection must be a maximum.
+	if sub[0] != all[0] {
+		t.Errorf("different best detection: want %v, got %v", all[0], sub[0])
+	}
+	// Must be a subset.
+	in := make(map[detect.Det]bool)
+	for _, det := range all {
+		in[det] = true
+	}
+	for _, det := range sub {
+		if !in[det] {
+			t.Errorf("detection not in original set: %v", det)
+		}
+	}
+}
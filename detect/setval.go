@@ -1,6 +1,7 @@
 package detect
 
 import (
+	"math"
 	"sort"
 
 	"github.com/jvlmdr/go-ml/ml"
@@ -192,3 +193,26 @@ func MissRateAtFPPI(valset *ValSet, fppi float64) float64 {
 	rates := MissRateAtFPPIs(valset, []float64{fppi})
 	return rates[0]
 }
+
+// CaltechFPPIs returns the nine false-positive-per-image rates
+// which are evenly spaced in log-space in [1e-2, 1e0].
+func CaltechFPPIs() []float64 {
+	fppis := make([]float64, 9)
+	for i := range fppis {
+		fppis[i] = math.Pow(10, -2+0.25*float64(i))
+	}
+	return fppis
+}
+
+// LogAvgMissRate computes the geometric mean of the miss rate
+// at multiple false-positive-per-image rates.
+// Miss rates of zero are replaced with 1e-10.
+// The Caltech Pedestrian benchmark uses CaltechFPPIs().
+func LogAvgMissRate(valset *ValSet, fppis []float64) float64 {
+	rates := MissRateAtFPPIs(valset, fppis)
+	var sum float64
+	for _, rate := range rates {
+		sum += math.Log(math.Max(1e-10, rate))
+	}
+	return math.Exp(sum / float64(len(rates)))
+}
@@ -1,6 +1,7 @@
 package detect_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/jvlmdr/go-cv/detect"
@@ -48,3 +49,32 @@ func TestMissRateAtFPPI(t *testing.T) {
 		}
 	}
 }
+
+func TestLogAvgMissRate(t *testing.T) {
+	fppis := detect.CaltechFPPIs()
+	if len(fppis) != 9 || math.Abs(fppis[0]-1e-2) > 1e-12 || math.Abs(fppis[8]-1) > 1e-12 {
+		t.Fatalf("wrong rates: %v", fppis)
+	}
+	valset := &detect.ValSet{
+		Dets: []detect.ValScore{
+			{5, true},
+			{4, false},
+			{3, true},
+			{2, false},
+			{1, true},
+		},
+		Misses: 1,
+		Images: 100,
+	}
+	// 1e-2 and 10^-1.75 admit 1 false positive (miss rate 2/4),
+	// the other rates admit both (miss rate 1/4).
+	want := math.Pow(0.5, 2.0/9) * math.Pow(0.25, 7.0/9)
+	if got := detect.LogAvgMissRate(valset, fppis); math.Abs(got-want) > 1e-9 {
+		t.Errorf("want %.6g, got %.6g", want, got)
+	}
+	// Zero miss rate is clamped.
+	valset = &detect.ValSet{Dets: []detect.ValScore{{1, true}}, Images: 1}
+	if got := detect.LogAvgMissRate(valset, fppis); math.Abs(got-1e-10) > 1e-20 {
+		t.Errorf("perfect: want 1e-10, got %g", got)
+	}
+}
@@ -0,0 +1,206 @@
+package detect
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// Suppressor performs an alternative to greedy non-max suppression
+// on a list of detections.
+// The result is ordered (descending) by score.
+type Suppressor interface {
+	Suppress(dets []Det) []Det
+}
+
+// SoftSupprFilter specifies parameters to SoftSuppress.
+//
+// Instead of discarding the detections which overlap a selected detection,
+// their scores are decayed according to their intersection-over-union.
+// The decay is either "linear" or "gaussian".
+//
+// Linear decay multiplies the score by (1 - IOU) if IOU is at least Thresh.
+// Gaussian decay multiplies the score by
+// 	exp(-IOU^2 / Sigma).
+// A multiplicative decay would increase a negative score,
+// therefore the scores must be calibrated to be non-negative
+// (for example using Platt) and MinScore must not be negative.
+type SoftSupprFilter struct {
+	Decay string
+	// In [0, 1].
+	Thresh float64
+	// Positive.
+	Sigma float64
+	// Detections whose score falls below MinScore are discarded.
+	// Must not be negative.
+	MinScore float64
+	// Maximum number of detections to return.
+	// Ignored if non-positive.
+	MaxNum int
+}
+
+// Suppress calls SoftSuppress.
+func (f SoftSupprFilter) Suppress(dets []Det) []Det {
+	return SoftSuppress(dets, f)
+}
+
+// SoftSuppress performs soft non-max suppression.
+// The detections do not need to be sorted.
+// Returns detections with decayed scores, ordered (descending) by score.
+// Detections with a negative score are discarded before suppression.
+// Panics if the decay is not recognized or the parameters are invalid.
+//
+// Bodla et al., "Soft-NMS: Improving Object Detection With One Line of Code", ICCV 2017.
+func SoftSuppress(dets []Det, opts SoftSupprFilter) []Det {
+	var decay func(iou float64) float64
+	switch opts.Decay {
+	case "linear":
+		decay = func(iou float64) float64 {
+			if iou < opts.Thresh {
+				return 1
+			}
+			return 1 - iou
+		}
+	case "gaussian":
+		decay = func(iou float64) float64 { return math.Exp(-iou * iou / opts.Sigma) }
+	default:
+		panic(fmt.Sprintf("unknown decay: %q", opts.Decay))
+	}
+	if opts.Decay == "gaussian" && !(opts.Sigma > 0) {
+		panic(fmt.Sprintf("sigma must be positive: %g", opts.Sigma))
+	}
+	if !(opts.Thresh >= 0 && opts.Thresh <= 1) {
+		panic(fmt.Sprintf("threshold must be in [0, 1]: %g", opts.Thresh))
+	}
+	if !(opts.MinScore >= 0) {
+		panic(fmt.Sprintf("min score must not be negative: %g (scores must be calibrated)", opts.MinScore))
+	}
+
+	rem := make([]Det, 0, len(dets))
+	for _, det := range dets {
+		if det.Score >= opts.MinScore {
+			rem = append(rem, det)
+		}
+	}
+	var subset []Det
+	for len(rem) > 0 && !(opts.MaxNum > 0 && len(subset) >= opts.MaxNum) {
+		// Select best remaining detection.
+		best := 0
+		for i := range rem {
+			if rem[i].Score > rem[best].Score {
+				best = i
+			}
+		}
+		sel := rem[best]
+		subset = append(subset, sel)
+		rem[best] = rem[len(rem)-1]
+		rem = rem[:len(rem)-1]
+		// Decay the others and remove those with insufficient score.
+		var n int
+		for _, det := range rem {
+			det.Score *= decay(IOU(sel.Rect, det.Rect))
+			if det.Score < opts.MinScore {
+				continue
+			}
+			rem[n] = det
+			n++
+		}
+		rem = rem[:n]
+	}
+	return subset
+}
+
+// FuseFilter specifies parameters to Fuse.
+type FuseFilter struct {
+	// A detection joins the cluster whose fused rectangle
+	// it overlaps the most if the intersection-over-union
+	// is greater than MinIOU.
+	MinIOU float64
+	// Converts a score to a weight, which must not be negative.
+	// If nil, the score itself is used,
+	// in which case the scores must be calibrated non-negative.
+	// For uncalibrated scores, use a non-negative function such as math.Exp.
+	Weight func(score float64) float64
+	// Maximum number of detections to return.
+	// Ignored if non-positive.
+	MaxNum int
+}
+
+// Suppress calls Fuse.
+func (f FuseFilter) Suppress(dets []Det) []Det {
+	return Fuse(dets, f)
+}
+
+// Fuse performs weighted box fusion on a sorted list of detections.
+//
+// Each detection in order of score either joins a cluster or starts a new one.
+// The rectangle of a cluster is the weighted mean of its members' rectangles.
+// The score of a cluster is the score of its first member.
+// If the total weight of a cluster is not positive,
+// then the rectangle of its first member is used.
+// Panics if the weight of a detection is negative,
+// since the fused rectangle could then lie outside its members.
+//
+// Solovyev et al., "Weighted boxes fusion: Ensembling boxes from different object detection models", 2019.
+func Fuse(dets []Det, opts FuseFilter) []Det {
+	if !sort.IsSorted(detsByScoreDesc(dets)) {
+		panic("not sorted")
+	}
+	weight := opts.Weight
+	if weight == nil {
+		weight = func(score float64) float64 { return score }
+	}
+	var clusters []*fuseCluster
+	for _, det := range dets {
+		w := weight(det.Score)
+		if !(w >= 0) {
+			panic(fmt.Sprintf("weight must be non-negative: score %g, weight %g (scores must be calibrated)", det.Score, w))
+		}
+		var (
+			best    *fuseCluster
+			bestIOU = opts.MinIOU
+		)
+		for _, c := range clusters {
+			if iou := IOU(c.Rect, det.Rect); iou > bestIOU {
+				best, bestIOU = c, iou
+			}
+		}
+		if best == nil {
+			if opts.MaxNum > 0 && len(clusters) >= opts.MaxNum {
+				continue
+			}
+			best = &fuseCluster{Det: det}
+			clusters = append(clusters, best)
+		}
+		best.add(det.Rect, w)
+	}
+	fused := make([]Det, len(clusters))
+	for i, c := range clusters {
+		fused[i] = c.Det
+	}
+	return fused
+}
+
+type fuseCluster struct {
+	// Current fused detection.
+	Det
+	// Weighted sum of coordinates and total weight.
+	sum   [4]float64
+	total float64
+}
+
+func (c *fuseCluster) add(r image.Rectangle, w float64) {
+	for i, x := range []int{r.Min.X, r.Min.Y, r.Max.X, r.Max.Y} {
+		c.sum[i] += w * float64(x)
+	}
+	c.total += w
+	if !(c.total > 0) {
+		return
+	}
+	var x [4]int
+	for i := range x {
+		x[i] = round(c.sum[i] / c.total)
+	}
+	c.Rect = image.Rect(x[0], x[1], x[2], x[3])
+}
@@ -0,0 +1,127 @@
+package detect_test
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+)
+
+func TestSoftSuppress(t *testing.T) {
+	a := image.Rect(0, 0, 100, 100)
+	// IOU with a is 60 / 140.
+	b := image.Rect(40, 0, 140, 100)
+	c := image.Rect(500, 0, 600, 100)
+	dets := []detect.Det{{0.5, c}, {0.8, b}, {1, a}}
+	iou := 60.0 / 140
+
+	// Linear decay below threshold does nothing.
+	got := detect.SoftSuppress(dets, detect.SoftSupprFilter{Decay: "linear", Thresh: 0.5})
+	want := []detect.Det{{1, a}, {0.8, b}, {0.5, c}}
+	errIfNotEqDets(t, "linear", want, got)
+
+	got = detect.SoftSuppress(dets, detect.SoftSupprFilter{Decay: "linear", Thresh: 0.3})
+	want = []detect.Det{{1, a}, {0.5, c}, {0.8 * (1 - iou), b}}
+	errIfNotEqDets(t, "linear", want, got)
+
+	got = detect.SoftSuppress(dets, detect.SoftSupprFilter{Decay: "gaussian", Sigma: 0.5})
+	want = []detect.Det{{1, a}, {0.8 * math.Exp(-iou*iou/0.5), b}, {0.5, c}}
+	errIfNotEqDets(t, "gaussian", want, got)
+
+	// Decayed detection is discarded.
+	got = detect.SoftSuppress(dets, detect.SoftSupprFilter{Decay: "linear", Thresh: 0.3, MinScore: 0.46})
+	want = []detect.Det{{1, a}, {0.5, c}}
+	errIfNotEqDets(t, "min score", want, got)
+}
+
+func TestSoftSuppress_negative(t *testing.T) {
+	a := image.Rect(0, 0, 100, 100)
+	b := image.Rect(40, 0, 140, 100)
+	dets := []detect.Det{{1, a}, {-0.5, b}}
+	// Negative scores are discarded rather than promoted by the decay.
+	got := detect.SoftSuppress(dets, detect.SoftSupprFilter{Decay: "linear", Thresh: 0.3})
+	want := []detect.Det{{1, a}}
+	errIfNotEqDets(t, "negative", want, got)
+	// Negative minimum score is rejected.
+	errIfNotPanic(t, "negative min score", func() {
+		detect.SoftSuppress(dets, detect.SoftSupprFilter{Decay: "linear", Thresh: 0.3, MinScore: -1})
+	})
+}
+
+func TestSoftSuppress_invalid(t *testing.T) {
+	dets := []detect.Det{{1, image.Rect(0, 0, 100, 100)}, {0.5, image.Rect(500, 0, 600, 100)}}
+	errIfNotPanic(t, "zero sigma", func() {
+		detect.SoftSuppress(dets, detect.SoftSupprFilter{Decay: "gaussian"})
+	})
+	errIfNotPanic(t, "threshold above one", func() {
+		detect.SoftSuppress(dets, detect.SoftSupprFilter{Decay: "linear", Thresh: 1.5})
+	})
+}
+
+func errIfNotPanic(t *testing.T, name string, f func()) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: did not panic", name)
+		}
+	}()
+	f()
+}
+
+func TestFuse(t *testing.T) {
+	dets := []detect.Det{
+		{3, image.Rect(0, 0, 100, 100)},
+		{2, image.Rect(500, 0, 600, 100)},
+		{1, image.Rect(30, 0, 130, 100)},
+	}
+	got := detect.Fuse(dets, detect.FuseFilter{MinIOU: 0.5})
+	// Weighted mean of 0 and 30 with weights 3 and 1 is 7.5.
+	want := []detect.Det{
+		{3, image.Rect(8, 0, 108, 100)},
+		{2, image.Rect(500, 0, 600, 100)},
+	}
+	errIfNotEqDets(t, "fuse", want, got)
+
+	got = detect.Fuse(dets, detect.FuseFilter{MinIOU: 0.5, MaxNum: 1})
+	errIfNotEqDets(t, "max num", want[:1], got)
+}
+
+func TestFuse_negative(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 100; trial++ {
+		// Detections overlap heavily and form a single cluster.
+		dets := make([]detect.Det, 2+r.Intn(4))
+		var hull image.Rectangle
+		for i := range dets {
+			x, y := r.Intn(20), r.Intn(20)
+			dets[i] = detect.Det{r.NormFloat64(), image.Rect(x, y, x+100+r.Intn(20), y+100+r.Intn(20))}
+			hull = hull.Union(dets[i].Rect)
+		}
+		detect.Sort(dets)
+		got := detect.Fuse(dets, detect.FuseFilter{Weight: math.Exp})
+		if len(got) != 1 {
+			t.Fatalf("want 1 cluster, got %d", len(got))
+		}
+		if !got[0].Rect.In(hull) {
+			t.Errorf("fused rectangle %v is outside members %v", got[0].Rect, hull)
+		}
+	}
+	// Negative weights are rejected.
+	dets := []detect.Det{{0.5, image.Rect(0, 0, 100, 100)}, {-0.4, image.Rect(10, 0, 110, 100)}}
+	errIfNotPanic(t, "negative weight", func() {
+		detect.Fuse(dets, detect.FuseFilter{})
+	})
+}
+
+func errIfNotEqDets(t *testing.T, name string, want, got []detect.Det) {
+	if len(want) != len(got) {
+		t.Errorf("%s: want %d detections, got %d", name, len(want), len(got))
+		return
+	}
+	for i := range want {
+		if want[i].Rect != got[i].Rect || math.Abs(want[i].Score-got[i].Score) > 1e-9 {
+			t.Errorf("%s: detection %d: want %v, got %v", name, i, want[i], got[i])
+		}
+	}
+}
@@ -9,4 +9,7 @@ type FeatTmpl struct {
 	// The size of the image from which the features were computed,
 	// and the position of the bounding box within it.
 	PixelShape PadRect
+	// If not nil, maps the score of the template
+	// to a value which is comparable to other templates.
+	Calib *CalibMarshaler `json:",omitempty"`
 }
@@ -2,6 +2,7 @@ package detect
 
 import (
 	"container/list"
+	"fmt"
 	"image"
 )
 
@@ -10,6 +11,9 @@ type Val struct {
 	True bool
 	// If so, give its corresponding annotation.
 	Ref image.Rectangle
+	// Was the detection ignored?
+	// Ignored detections are neither true nor false.
+	Ignore bool
 }
 
 // ValDet describes a validated detection,
@@ -31,10 +35,14 @@ type ValImage struct {
 
 // Scores extracts just the scores of the validated detections,
 // discarding their location.
+// Ignored detections are omitted.
 func (im *ValImage) Scores() []ValScore {
-	scores := make([]ValScore, len(im.Dets))
-	for i, det := range im.Dets {
-		scores[i] = ValScore{det.Score, det.True}
+	scores := make([]ValScore, 0, len(im.Dets))
+	for _, det := range im.Dets {
+		if det.Ignore {
+			continue
+		}
+		scores = append(scores, ValScore{det.Score, det.True})
 	}
 	return scores
 }
@@ -53,8 +61,16 @@ func (im *ValImage) Set() *ValSet {
 //
 // Sufficient overlap to match a reference is assessed using intersection-over-union.
 // Sufficient overlap to ignore a detection is assessed by what fraction of the detection is covered.
+// Ignored detections are neither true nor false positives
+// and ignored regions are never counted as misses.
+// For example, the "difficult" objects in PASCAL VOC should be ignored.
 func Validate(dets []Det, refs, ignore []image.Rectangle, refMinIOU, ignoreMinCover float64) *ValImage {
-	vals, miss := ValidateList(DetSlice(dets), refs, ignore, refMinIOU, ignoreMinCover)
+	return ValidateMatch(dets, refs, ignore, refMinIOU, ignoreMinCover, MatchGreedy)
+}
+
+// ValidateMatch is like Validate but uses the given matching strategy.
+func ValidateMatch(dets []Det, refs, ignore []image.Rectangle, refMinIOU, ignoreMinCover float64, strategy MatchStrategy) *ValImage {
+	vals, miss := ValidateListMatch(DetSlice(dets), refs, ignore, refMinIOU, ignoreMinCover, strategy)
 	valdets := make([]ValDet, len(dets))
 	for i := range dets {
 		valdets[i] = ValDet{dets[i], vals[i]}
@@ -63,8 +79,23 @@ func Validate(dets []Det, refs, ignore []image.Rectangle, refMinIOU, ignoreMinCo
 }
 
 func ValidateList(dets DetList, refs, ignore []image.Rectangle, refMinIOU, ignoreMinCover float64) (vals []Val, miss []image.Rectangle) {
+	return ValidateListMatch(dets, refs, ignore, refMinIOU, ignoreMinCover, MatchGreedy)
+}
+
+// ValidateListMatch is like ValidateList but uses the given matching strategy.
+func ValidateListMatch(dets DetList, refs, ignore []image.Rectangle, refMinIOU, ignoreMinCover float64, strategy MatchStrategy) (vals []Val, miss []image.Rectangle) {
 	// Match rectangles and then remove any detections (incorrect or otherwise) which are ignored.
-	m := Match(dets, refs, refMinIOU)
+	var m map[int]int
+	switch strategy {
+	case MatchGreedy:
+		m = Match(dets, refs, refMinIOU)
+	case MatchOptimal:
+		m = MatchOptimalIOU(dets, refs, refMinIOU)
+	case MatchPreferRefs:
+		m = matchPreferRefs(dets, refs, ignore, refMinIOU, ignoreMinCover)
+	default:
+		panic(fmt.Sprintf("unknown match strategy: %d", strategy))
+	}
 	// Label each detection as true positive or false positive.
 	vals = make([]Val, dets.Len())
 	// Record which references were matched.
@@ -76,6 +107,7 @@ func ValidateList(dets DetList, refs, ignore []image.Rectangle, refMinIOU, ignor
 			// Detection did not have a match.
 			// Check whether to ignore the false positive.
 			if anyCovers(ignore, det.Rect, ignoreMinCover) {
+				vals[i] = Val{Ignore: true}
 				continue
 			}
 			vals[i] = Val{True: false}
@@ -0,0 +1,9 @@
+/*
+Package dpm implements deformable part models.
+
+A model comprises a root template and part templates
+which are evaluated at twice the resolution of the root.
+The placement of each part is optimized using
+a generalized distance transform.
+*/
+package dpm
@@ -0,0 +1,170 @@
+package dpm
+
+import (
+	"image"
+	"math"
+
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+// QuadCost is a separable quadratic deformation cost.
+// The cost of displacement (dx, dy) is
+// 	XX*dx^2 + X*dx + YY*dy^2 + Y*dy
+type QuadCost struct {
+	XX, X, YY, Y float64
+}
+
+// Eval returns the cost of displacement d.
+func (c QuadCost) Eval(d image.Point) float64 {
+	dx, dy := float64(d.X), float64(d.Y)
+	return c.XX*dx*dx + c.X*dx + c.YY*dy*dy + c.Y*dy
+}
+
+// ArgMax is an image of positions.
+type ArgMax struct {
+	// Element (x, y) at index x*Height + y.
+	Elems  []image.Point
+	Width  int
+	Height int
+}
+
+func newArgMax(width, height int) *ArgMax {
+	return &ArgMax{make([]image.Point, width*height), width, height}
+}
+
+// At retrieves an element of the image.
+func (f *ArgMax) At(x, y int) image.Point {
+	return f.Elems[x*f.Height+y]
+}
+
+func (f *ArgMax) set(x, y int, p image.Point) {
+	f.Elems[x*f.Height+y] = p
+}
+
+// DistTrans computes the generalized distance transform of f
+// using the algorithm of Felzenszwalb and Huttenlocher.
+// 	g[p] = max_q f[q] - cost(q - p)
+// It also returns the maximizing q for every p.
+// The output has the same size as f.
+//
+// Panics if either quadratic coefficient is not positive.
+func DistTrans(f *rimg64.Image, cost QuadCost) (*rimg64.Image, *ArgMax) {
+	return DistTransRect(f, cost, image.Rectangle{Max: f.Size()})
+}
+
+// DistTransRect computes the generalized distance transform of f
+// for every p in the rectangle r.
+// The rectangle may extend beyond the domain of f.
+// Pixel (i, j) of the output corresponds to p = r.Min + (i, j).
+// The maximizing positions q are always within f.
+//
+// Panics if either quadratic coefficient is not positive.
+func DistTransRect(f *rimg64.Image, cost QuadCost, r image.Rectangle) (*rimg64.Image, *ArgMax) {
+	if !(cost.XX > 0 && cost.YY > 0) {
+		panic("quadratic coefficients must be positive")
+	}
+	if f.Width <= 0 || f.Height <= 0 || r.Empty() {
+		return nil, nil
+	}
+	n := max(max(f.Width, f.Height), max(r.Dx(), r.Dy()))
+	buf := newDTBuffer(n)
+
+	// Transform along x for every row.
+	tmp := rimg64.New(r.Dx(), f.Height)
+	argx := make([]int, r.Dx()*f.Height)
+	src := make([]float64, f.Width)
+	dst := make([]float64, r.Dx())
+	arg := make([]int, r.Dx())
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			src[x] = f.At(x, y)
+		}
+		distTrans1(src, cost.XX, cost.X, r.Min.X, dst, arg, buf)
+		for i := range dst {
+			tmp.Set(i, y, dst[i])
+			argx[i*f.Height+y] = arg[i]
+		}
+	}
+
+	// Transform along y for every column.
+	g := rimg64.New(r.Dx(), r.Dy())
+	q := newArgMax(r.Dx(), r.Dy())
+	src = make([]float64, f.Height)
+	dst = make([]float64, r.Dy())
+	arg = make([]int, r.Dy())
+	for i := 0; i < r.Dx(); i++ {
+		for y := 0; y < f.Height; y++ {
+			src[y] = tmp.At(i, y)
+		}
+		distTrans1(src, cost.YY, cost.Y, r.Min.Y, dst, arg, buf)
+		for j := range dst {
+			g.Set(i, j, dst[j])
+			q.set(i, j, image.Pt(argx[i*f.Height+arg[j]], arg[j]))
+		}
+	}
+	return g, q
+}
+
+// Working memory for the lower envelope.
+type dtBuffer struct {
+	// Indices of parabolas in the envelope.
+	v []int
+	// Boundaries between parabolas.
+	z []float64
+}
+
+func newDTBuffer(n int) *dtBuffer {
+	return &dtBuffer{make([]int, n), make([]float64, n+1)}
+}
+
+// Computes the one-dimensional transform
+// 	out[i] = max_q f[q] - (a*d^2 + b*d),
+// where d = q - p and p = lo + i.
+// The maximizing q is stored in arg[i].
+//
+// The maximum is found as the lower envelope of the parabolas
+// 	h_q(p) = -f[q] + a*(q-p)^2 + b*(q-p).
+func distTrans1(f []float64, a, b float64, lo int, out []float64, arg []int, buf *dtBuffer) {
+	v, z := buf.v, buf.z
+	// Value of parabola q at p = 0.
+	h := func(q int) float64 {
+		x := float64(q)
+		return -f[q] + a*x*x + b*x
+	}
+	k := 0
+	v[0] = 0
+	z[0] = math.Inf(-1)
+	z[1] = math.Inf(1)
+	for q := 1; q < len(f); q++ {
+		var s float64
+		for {
+			// Intersection of parabola q with rightmost parabola of envelope.
+			s = (h(q) - h(v[k])) / (2 * a * float64(q-v[k]))
+			if s > z[k] || k == 0 {
+				break
+			}
+			k--
+		}
+		if s <= z[k] {
+			// Parabola q is below the entire envelope.
+			v[k] = q
+			z[k+1] = math.Inf(1)
+			continue
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+
+	k = 0
+	for i := range out {
+		p := lo + i
+		for z[k+1] < float64(p) {
+			k++
+		}
+		d := float64(v[k] - p)
+		out[i] = f[v[k]] - (a*d*d + b*d)
+		arg[i] = v[k]
+	}
+}
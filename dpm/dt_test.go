@@ -0,0 +1,82 @@
+package dpm_test
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/dpm"
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+func TestDistTrans(t *testing.T) {
+	const eps = 1e-9
+	f := randImage(17, 12)
+	cost := dpm.QuadCost{XX: 0.1, X: 0.05, YY: 0.3, Y: -0.2}
+	g, arg := dpm.DistTrans(f, cost)
+	r := image.Rectangle{Max: f.Size()}
+	if err := errIfNotDistTrans(f, cost, r, g, arg, eps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDistTransRect(t *testing.T) {
+	const eps = 1e-9
+	f := randImage(17, 12)
+	cost := dpm.QuadCost{XX: 0.5, X: 0, YY: 0.01, Y: 0.1}
+	// Rectangle extends beyond image on all sides.
+	r := image.Rect(-5, -3, 25, 16)
+	g, arg := dpm.DistTransRect(f, cost, r)
+	if err := errIfNotDistTrans(f, cost, r, g, arg, eps); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Compares the transform to exhaustive search.
+func errIfNotDistTrans(f *rimg64.Image, cost dpm.QuadCost, r image.Rectangle, g *rimg64.Image, arg *dpm.ArgMax, eps float64) error {
+	if !g.Size().Eq(r.Size()) {
+		return fmt.Errorf("wrong size: want %v, got %v", r.Size(), g.Size())
+	}
+	for i := 0; i < r.Dx(); i++ {
+		for j := 0; j < r.Dy(); j++ {
+			p := r.Min.Add(image.Pt(i, j))
+			best := math.Inf(-1)
+			for x := 0; x < f.Width; x++ {
+				for y := 0; y < f.Height; y++ {
+					q := image.Pt(x, y)
+					best = math.Max(best, f.At(x, y)-cost.Eval(q.Sub(p)))
+				}
+			}
+			if math.Abs(best-g.At(i, j)) > eps {
+				return fmt.Errorf("at %v: want %.6g, got %.6g", p, best, g.At(i, j))
+			}
+			// Check that the argmax attains the maximum.
+			q := arg.At(i, j)
+			if !q.In(image.Rectangle{Max: f.Size()}) {
+				return fmt.Errorf("at %v: argmax %v outside image", p, q)
+			}
+			if val := f.At(q.X, q.Y) - cost.Eval(q.Sub(p)); math.Abs(best-val) > eps {
+				return fmt.Errorf("at %v: argmax %v attains %.6g, want %.6g", p, q, val, best)
+			}
+		}
+	}
+	return nil
+}
+
+func randImage(width, height int) *rimg64.Image {
+	f := rimg64.New(width, height)
+	for i := range f.Elems {
+		f.Elems[i] = rand.NormFloat64()
+	}
+	return f
+}
+
+func randMulti(width, height, channels int) *rimg64.Multi {
+	f := rimg64.NewMulti(width, height, channels)
+	for i := range f.Elems {
+		f.Elems[i] = rand.NormFloat64()
+	}
+	return f
+}
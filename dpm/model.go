@@ -0,0 +1,148 @@
+package dpm
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/slide"
+)
+
+// Model is a star-structured deformable part model.
+//
+// The root template is evaluated at the resolution of the detection window.
+// The part templates are evaluated at twice that resolution.
+// The score of a root position x is
+// 	root(x) + sum_i max_d [part_i(2x + anchor_i + d) - cost_i(d)].
+type Model struct {
+	Root  *slide.AffineScorer
+	Parts []*Part
+}
+
+// Tmpl is a part model with the shape of its detection window.
+// It can be serialized as JSON.
+type Tmpl struct {
+	Model *Model
+	// The size of the image from which the root features are computed,
+	// and the position of the bounding box within it.
+	PixelShape detect.PadRect
+}
+
+// Part is a template with a preferred position relative to the root.
+type Part struct {
+	Scorer *slide.AffineScorer
+	// Position of the part at twice the resolution of the root
+	// relative to the top-left corner of the root.
+	Anchor image.Point
+	// Cost of displacement from the anchor.
+	Cost QuadCost
+}
+
+// Size returns the size of the root template.
+func (m *Model) Size() image.Point {
+	return m.Root.Size()
+}
+
+// Response is the result of evaluating a model at every position.
+type Response struct {
+	// Score of every root position.
+	Score *rimg64.Image
+	// Part placements for every root position.
+	// Placements are positions in the part features.
+	Parts []*ArgMax
+}
+
+// Placement returns the optimal position of each part
+// for the root at (x, y).
+func (r *Response) Placement(x, y int) []image.Point {
+	pts := make([]image.Point, len(r.Parts))
+	for i, arg := range r.Parts {
+		pts[i] = arg.At(x, y)
+	}
+	return pts
+}
+
+// Slide evaluates the model at every position in the root features.
+//
+// The part features are computed at twice the resolution.
+// Position 2x + offset in the part features must correspond to
+// position x in the root features.
+// Parts are placed only where they lie within the part features.
+//
+// Returns nil if the root features are smaller than the root template
+// or the part features are smaller than some part template.
+func (m *Model) Slide(root, parts *rimg64.Multi, offset image.Point) (*Response, error) {
+	score, err := m.Root.Slide(root)
+	if err != nil {
+		return nil, err
+	}
+	if score == nil {
+		return nil, nil
+	}
+	if len(m.Parts) == 0 {
+		return &Response{Score: score}, nil
+	}
+	list, err := m.partList()
+	if err != nil {
+		return nil, err
+	}
+	defer list.Destroy()
+	return m.slide(score, parts, offset, list)
+}
+
+// Evaluates the parts given the response of the root.
+// The part templates are evaluated jointly using list.
+func (m *Model) slide(score *rimg64.Image, parts *rimg64.Multi, offset image.Point, list *slide.AffineList) (*Response, error) {
+	resp := &Response{Score: score, Parts: make([]*ArgMax, len(m.Parts))}
+	partResps, err := list.Slide(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rectangle of anchor positions at twice the resolution.
+	grid := image.Rect(0, 0, 2*score.Width-1, 2*score.Height-1)
+	for i, part := range m.Parts {
+		if partResps[i] == nil {
+			return nil, nil
+		}
+		r := grid.Add(offset).Add(part.Anchor)
+		dt, arg := DistTransRect(partResps[i], part.Cost, r)
+		// Sub-sample transform at every second position.
+		place := newArgMax(score.Width, score.Height)
+		for x := 0; x < score.Width; x++ {
+			for y := 0; y < score.Height; y++ {
+				score.Set(x, y, score.At(x, y)+dt.At(2*x, 2*y))
+				place.set(x, y, arg.At(2*x, 2*y))
+			}
+		}
+		resp.Parts[i] = place
+	}
+	return resp, nil
+}
+
+// Validate checks that the parts of the model are consistent.
+func (m *Model) Validate() error {
+	if m.Root == nil {
+		return fmt.Errorf("no root template")
+	}
+	for i, part := range m.Parts {
+		if part.Scorer.Tmpl.Channels != m.Root.Tmpl.Channels {
+			return fmt.Errorf("part %d: different channels: root %d, part %d",
+				i, m.Root.Tmpl.Channels, part.Scorer.Tmpl.Channels)
+		}
+		if !(part.Cost.XX > 0 && part.Cost.YY > 0) {
+			return fmt.Errorf("part %d: quadratic cost is not positive: %+v", i, part.Cost)
+		}
+	}
+	return nil
+}
+
+// Creates a list for evaluating all part templates on the same image.
+func (m *Model) partList() (*slide.AffineList, error) {
+	scorers := make([]*slide.AffineScorer, len(m.Parts))
+	for i, part := range m.Parts {
+		scorers[i] = part.Scorer
+	}
+	return slide.NewAffineList(scorers)
+}
@@ -0,0 +1,87 @@
+package dpm_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/dpm"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/slide"
+)
+
+func TestModel_Slide(t *testing.T) {
+	const (
+		channels = 3
+		eps      = 1e-9
+	)
+	model := &dpm.Model{
+		Root: &slide.AffineScorer{Tmpl: randMulti(4, 6, channels), Bias: -1},
+		Parts: []*dpm.Part{
+			{
+				Scorer: &slide.AffineScorer{Tmpl: randMulti(3, 3, channels)},
+				Anchor: image.Pt(1, 2),
+				Cost:   dpm.QuadCost{XX: 0.1, X: 0, YY: 0.1, Y: 0},
+			},
+			{
+				Scorer: &slide.AffineScorer{Tmpl: randMulti(4, 2, channels), Bias: 0.5},
+				Anchor: image.Pt(4, 8),
+				Cost:   dpm.QuadCost{XX: 0.5, X: 0.1, YY: 0.2, Y: -0.1},
+			},
+		},
+	}
+	root := randMulti(12, 14, channels)
+	parts := randMulti(24, 28, channels)
+	offset := image.Pt(-1, -1)
+	resp, err := model.Slide(root, parts, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootResp, err := model.Root.Slide(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Score.Size().Eq(rootResp.Size()) {
+		t.Fatalf("wrong size: want %v, got %v", rootResp.Size(), resp.Score.Size())
+	}
+	partResps := make([]*rimg64.Image, len(model.Parts))
+	for i, part := range model.Parts {
+		partResps[i], err = part.Scorer.Slide(parts)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for x := 0; x < rootResp.Width; x++ {
+		for y := 0; y < rootResp.Height; y++ {
+			// Find best placement of each part exhaustively.
+			want := rootResp.At(x, y)
+			for i, part := range model.Parts {
+				anchor := image.Pt(2*x, 2*y).Add(offset).Add(part.Anchor)
+				best := math.Inf(-1)
+				r := partResps[i]
+				for u := 0; u < r.Width; u++ {
+					for v := 0; v < r.Height; v++ {
+						d := image.Pt(u, v).Sub(anchor)
+						best = math.Max(best, r.At(u, v)-part.Cost.Eval(d))
+					}
+				}
+				want += best
+			}
+			if got := resp.Score.At(x, y); math.Abs(want-got) > eps {
+				t.Fatalf("at (%d, %d): want %.6g, got %.6g", x, y, want, got)
+			}
+
+			// Check that placements attain the score.
+			got := rootResp.At(x, y)
+			for i, q := range resp.Placement(x, y) {
+				part := model.Parts[i]
+				anchor := image.Pt(2*x, 2*y).Add(offset).Add(part.Anchor)
+				got += partResps[i].At(q.X, q.Y) - part.Cost.Eval(q.Sub(anchor))
+			}
+			if math.Abs(want-got) > eps {
+				t.Fatalf("at (%d, %d): placement gives %.6g, want %.6g", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package dpm
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/featpyr"
+	"github.com/jvlmdr/go-cv/imgpyr"
+	"github.com/jvlmdr/go-cv/metrics"
+	"github.com/jvlmdr/go-cv/slide"
+)
+
+// Relative tolerance when searching for the level at twice the scale.
+const octaveTol = 1e-3
+
+// MultiScale searches an image at multiple scales and performs non-max suppression.
+//
+// The pyramid is constructed as in detect.MultiScale.
+// If the model has parts, then the root is evaluated at every level for which
+// there is an earlier level at twice the scale,
+// therefore PyrStep must divide an octave into an integer number of steps.
+// To evaluate the root at the original resolution, set MaxScale to 2.
+//
+// The margin added by Pad must be a multiple of the feature rate.
+//
+// Greedy non-max suppression is always performed using SupprFilter.
+// An error is returned if Suppressor or DetFilter.ScaleMax is set.
+// Workers, Progress and Metrics are ignored.
+func MultiScale(im image.Image, model *Model, shape detect.PadRect, opts detect.MultiScaleOpts) ([]Det, error) {
+	if opts.Suppressor != nil {
+		return nil, errors.New("suppressor is not supported")
+	}
+	if opts.DetFilter.ScaleMax {
+		return nil, errors.New("maxima over scale are not supported")
+	}
+	var dets []Det
+	err := walk(im, model, opts, func(pyr *featpyr.Generator, root, part *featpyr.Level, resp *Response) error {
+		dets = append(dets, levelDets(pyr, root, part, model, resp, shape, opts.DetFilter)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	Sort(dets)
+	inds := detect.SuppressIndex(DetSlice(dets), opts.SupprFilter.MaxNum, opts.SupprFilter.Overlap)
+	return detsSubset(dets, inds), nil
+}
+
+// Detector performs detection with a trained model.
+// It satisfies batch.Detector in package detect/batch,
+// which is how the detect packages use a part model:
+// detect.MultiScale cannot evaluate it
+// since the parts are evaluated at a second level of the pyramid.
+type Detector struct {
+	Tmpl *Tmpl
+	Opts detect.MultiScaleOpts
+}
+
+// Detect calls MultiScale and discards the placement of the parts.
+// No measurements are reported to rec.
+func (d *Detector) Detect(im image.Image, rec metrics.Recorder) ([]detect.Det, error) {
+	dets, err := MultiScale(im, d.Tmpl.Model, d.Tmpl.PixelShape, d.Opts)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]detect.Det, len(dets))
+	for i, det := range dets {
+		roots[i] = det.Det
+	}
+	return roots, nil
+}
+
+// Called for each level at which the root is evaluated.
+// The part level is nil if the model has no parts.
+type levelFunc func(pyr *featpyr.Generator, root, part *featpyr.Level, resp *Response) error
+
+// Evaluates the model at every level of the pyramid.
+// Calls fn for each level with a non-nil response.
+func walk(im image.Image, model *Model, opts detect.MultiScaleOpts, fn levelFunc) error {
+	if err := model.Validate(); err != nil {
+		return err
+	}
+	offset, err := partOffset(opts)
+	if err != nil {
+		return err
+	}
+	var list *slide.AffineList
+	if len(model.Parts) > 0 {
+		list, err = model.partList()
+		if err != nil {
+			return err
+		}
+		defer list.Destroy()
+	}
+
+	scales := imgpyr.Scales(im.Bounds().Size(), model.Size(), opts.MaxScale, opts.PyrStep).Elems()
+	ims := imgpyr.NewGenerator(im, scales, opts.Interp)
+	pyr := featpyr.NewGenerator(ims, opts.Transform, opts.Pad)
+	// Levels which may be needed for parts.
+	var prev []*featpyr.Level
+	l, err := pyr.First()
+	if err != nil {
+		return err
+	}
+	for l != nil {
+		var part *featpyr.Level
+		if len(model.Parts) > 0 {
+			scale := scales[l.Image.Index]
+			// Discard levels which are more than twice this scale.
+			for len(prev) > 0 && scales[prev[0].Image.Index] > 2*scale*(1+octaveTol) {
+				prev = prev[1:]
+			}
+			if len(prev) > 0 && math.Abs(scales[prev[0].Image.Index]/(2*scale)-1) <= octaveTol {
+				part = prev[0]
+			}
+			prev = append(prev, l)
+		}
+		if part != nil || len(model.Parts) == 0 {
+			resp, err := evalLevel(model, l, part, offset, list)
+			if err != nil {
+				return err
+			}
+			if resp != nil {
+				if err := fn(pyr, l, part, resp); err != nil {
+					return err
+				}
+			}
+		}
+		l, err = pyr.Next(l)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func evalLevel(model *Model, root, part *featpyr.Level, offset image.Point, list *slide.AffineList) (*Response, error) {
+	score, err := model.Root.Slide(root.Feat)
+	if err != nil {
+		return nil, err
+	}
+	if score == nil {
+		return nil, nil
+	}
+	if len(model.Parts) == 0 {
+		return &Response{Score: score}, nil
+	}
+	return model.slide(score, part.Feat, offset, list)
+}
+
+// Returns the offset such that position 2x + offset in the part level
+// corresponds to position x in the root level.
+func partOffset(opts detect.MultiScaleOpts) (image.Point, error) {
+	rate := opts.Transform.Rate()
+	margin := opts.Pad.Margin.TopLeft()
+	if margin.X%rate != 0 || margin.Y%rate != 0 {
+		return image.ZP, fmt.Errorf("margin %v is not a multiple of rate %d", margin, rate)
+	}
+	return margin.Div(rate).Mul(-1), nil
+}
+
+// Converts the response at one level to detections in the image.
+func levelDets(pyr *featpyr.Generator, root, part *featpyr.Level, model *Model, resp *Response, shape detect.PadRect, filter detect.DetFilter) []Det {
+	rate := pyr.Transform.Rate()
+	var dets []Det
+	pts := detect.RespPoints(resp.Score, filter.LocalMax, filter.MinScore)
+	for _, pt := range pts {
+		rect := pyr.ToImageRect(root.Image.Index, pt.Point, shape.Int)
+		parts := make([]image.Rectangle, len(model.Parts))
+		for i, q := range resp.Placement(pt.X, pt.Y) {
+			size := model.Parts[i].Scorer.Size().Mul(rate)
+			parts[i] = pyr.ToImageRect(part.Image.Index, q, image.Rectangle{Max: size})
+		}
+		dets = append(dets, Det{detect.Det{pt.Score, rect}, parts})
+	}
+	return dets
+}
@@ -0,0 +1,80 @@
+package dpm
+
+import "math/rand"
+
+// Minimizes the SVM objective
+// 	1/2 ||w||^2 + C sum_i max(0, 1 - y_i w' x_i)
+// subject to w[k] >= lower for every k in bounded
+// using projected stochastic sub-gradient descent (Pegasos).
+// The labels of pos and neg are +1 and -1.
+//
+// The result is the average of the iterates in the final epoch.
+//
+// The deformation coefficients are bounded below
+// so that the distance transform is well defined.
+// The dual solver in package svm does not support the bound.
+func trainSGD(pos, neg [][]float64, c float64, epochs int, bounded []int, lower float64, r *rand.Rand) []float64 {
+	n := len(pos) + len(neg)
+	if n == 0 {
+		return nil
+	}
+	var dim int
+	if len(pos) > 0 {
+		dim = len(pos[0])
+	} else {
+		dim = len(neg[0])
+	}
+	lambda := 1 / (c * float64(n))
+
+	w := make([]float64, dim)
+	avg := make([]float64, dim)
+	var t int
+	for epoch := 0; epoch < epochs; epoch++ {
+		for _, i := range r.Perm(n) {
+			t++
+			x, y := pos, 1.0
+			if i >= len(pos) {
+				x, y, i = neg, -1.0, i-len(pos)
+			}
+			xi := x[i]
+			eta := 1 / (lambda * float64(t))
+			// Evaluate margin before shrinking.
+			margin := y * dot(w, xi)
+			scaleVec(1-eta*lambda, w)
+			if margin < 1 {
+				addScaled(w, eta*y, xi)
+			}
+			for _, k := range bounded {
+				if w[k] < lower {
+					w[k] = lower
+				}
+			}
+			if epoch == epochs-1 {
+				addScaled(avg, 1/float64(n), w)
+			}
+		}
+	}
+	return avg
+}
+
+func dot(x, y []float64) float64 {
+	var s float64
+	for i := range x {
+		s += x[i] * y[i]
+	}
+	return s
+}
+
+// y <- y + alpha x
+func addScaled(y []float64, alpha float64, x []float64) {
+	for i := range x {
+		y[i] += alpha * x[i]
+	}
+}
+
+// x <- alpha x
+func scaleVec(alpha float64, x []float64) {
+	for i := range x {
+		x[i] *= alpha
+	}
+}
@@ -0,0 +1,453 @@
+package dpm
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/feat"
+	"github.com/jvlmdr/go-cv/featpyr"
+	"github.com/jvlmdr/go-cv/featset"
+	"github.com/jvlmdr/go-cv/imgpyr"
+	"github.com/jvlmdr/go-cv/imsamp"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/slide"
+	"github.com/nfnt/resize"
+)
+
+// Lower bound on the quadratic deformation coefficients during training.
+const minQuad = 0.01
+
+// Deformation cost of a new part.
+var initCost = QuadCost{XX: 0.1, YY: 0.1}
+
+// Negative windows must score above -negMargin to be hard.
+const negMargin = 1
+
+// Pos is a positive example.
+type Pos struct {
+	Image image.Image
+	// Bounding box of the object.
+	// It is aligned to the detection window using FitRect.
+	Rect image.Rectangle
+}
+
+// TrainOpts specifies the parameters to Train().
+type TrainOpts struct {
+	// Size of the detection window in pixels and
+	// the position of the bounding box within it.
+	PixelShape detect.PadRect
+	// Mode used by FitRect to align positive boxes to PixelShape.
+	AspectMode string
+
+	// Pyramid parameters as in detect.MultiScaleOpts.
+	// MaxScale is only used for negative images.
+	Transform featset.Image
+	feat.Pad
+	MaxScale float64
+	PyrStep  float64
+	Interp   resize.InterpolationFunction
+
+	// Number of parts and their size in cells at twice the root resolution.
+	NumParts int
+	PartSize image.Point
+
+	// Regularization trade-off parameter of the SVM.
+	C float64
+	// Value of the constant feature whose weight is the bias.
+	// Larger values reduce the regularization of the bias.
+	// If zero, then the model has no bias.
+	BiasFeat float64
+	// Number of passes over the training set per optimization.
+	Epochs int
+
+	// Number of rounds of relabelling the positive examples.
+	LatentRounds int
+	// Number of rounds of hard negative mining for each relabelling,
+	// and for the root before adding parts.
+	MiningRounds int
+	// Minimum IOU of latent root placements with positive boxes.
+	MinIOU float64
+	// Number of random windows per negative image used to initialize the root.
+	InitNegPerImage int
+	// Maximum number of hard negatives taken from each image per round.
+	NegPerImage int
+	// Maximum number of negative examples in memory.
+	CacheSize int
+	// Seed for the random number generator.
+	Seed int64
+}
+
+func (opts TrainOpts) pyrOpts(maxScale float64) detect.MultiScaleOpts {
+	return detect.MultiScaleOpts{
+		MaxScale:  maxScale,
+		PyrStep:   opts.PyrStep,
+		Interp:    opts.Interp,
+		Transform: opts.Transform,
+		Pad:       opts.Pad,
+	}
+}
+
+func (opts TrainOpts) validate() error {
+	if opts.Transform == nil {
+		return errors.New("no feature transform")
+	}
+	if !(opts.C > 0) {
+		return fmt.Errorf("C must be positive: %g", opts.C)
+	}
+	if opts.Epochs < 1 {
+		return fmt.Errorf("need at least one epoch: %d", opts.Epochs)
+	}
+	if opts.CacheSize < 1 {
+		return fmt.Errorf("cache size must be positive: %d", opts.CacheSize)
+	}
+	if opts.NumParts > 0 && (opts.PartSize.X <= 0 || opts.PartSize.Y <= 0) {
+		return fmt.Errorf("part size must be positive: %v", opts.PartSize)
+	}
+	return nil
+}
+
+// Train learns a star-structured part model using latent SVM.
+//
+// The root is first trained on the warped positive examples
+// and random windows from the negative images,
+// followed by rounds of hard negative mining.
+// Parts are then initialized from the root and the model is trained by
+// alternating between choosing the latent root and part placement
+// of every positive example and rounds of hard negative mining.
+// Each optimization is convex.
+//
+// The result can be serialized as JSON and used for detection
+// with MultiScale or Detector.
+func Train(pos []Pos, neg []image.Image, opts TrainOpts) (*Tmpl, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewSource(opts.Seed))
+
+	// Initialize root from warped positives.
+	posVecs, err := warpPos(pos, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(posVecs) == 0 {
+		return nil, errors.New("no positive examples")
+	}
+	size := opts.Transform.Size(opts.PixelShape.Size)
+	model := &Model{Root: &slide.AffineScorer{Tmpl: rimg64.NewMulti(size.X, size.Y, opts.Transform.Channels())}}
+	cache := detect.NewNegCache(opts.CacheSize)
+	for i, im := range neg {
+		vecs, err := randNeg(im, model, opts, r)
+		if err != nil {
+			return nil, err
+		}
+		for j, x := range vecs {
+			cache.Add(negWindow(i, imgpyr.Point{Level: -1, Pos: image.Pt(j, 0)}, x, 0))
+		}
+	}
+	if err := mine(model, posVecs, neg, cache, opts, r); err != nil {
+		return nil, err
+	}
+
+	if opts.NumParts > 0 {
+		if err := initParts(model, opts.NumParts, opts.PartSize); err != nil {
+			return nil, err
+		}
+		// Dimension of feature vectors has changed.
+		cache = detect.NewNegCache(opts.CacheSize)
+	}
+	for round := 0; round < opts.LatentRounds; round++ {
+		posVecs, err = relabel(pos, model, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(posVecs) == 0 {
+			return nil, errors.New("no positive examples could be placed")
+		}
+		if err := mine(model, posVecs, neg, cache, opts, r); err != nil {
+			return nil, err
+		}
+	}
+	return &Tmpl{model, opts.PixelShape}, nil
+}
+
+// Performs rounds of hard negative mining and optimization.
+// If the cache contains examples, then the model is trained before mining.
+func mine(model *Model, posVecs [][]float64, neg []image.Image, cache *detect.NegCache, opts TrainOpts, r *rand.Rand) error {
+	if len(cache.Windows) > 0 {
+		optimize(model, posVecs, cache, opts, r)
+	}
+	for round := 0; round < opts.MiningRounds; round++ {
+		for i, im := range neg {
+			if err := mineImage(cache, i, im, model, opts); err != nil {
+				return err
+			}
+		}
+		if len(cache.Windows) == 0 {
+			// No negative scores above the margin.
+			break
+		}
+		optimize(model, posVecs, cache, opts, r)
+	}
+	return nil
+}
+
+// Trains the model and evicts easy negatives from the cache.
+func optimize(model *Model, posVecs [][]float64, cache *detect.NegCache, opts TrainOpts, r *rand.Rand) {
+	feats := cache.Feats()
+	negVecs := make([][]float64, len(feats))
+	for i, x := range feats {
+		negVecs[i] = x.Elems
+	}
+	w := trainSGD(posVecs, negVecs, opts.C, opts.Epochs, model.quadIndices(), minQuad, r)
+	model.setVec(w, opts.BiasFeat)
+	// Cannot fail: every vector has the same length as w.
+	if _, err := cache.Evict(vecScorer(w), -negMargin); err != nil {
+		panic(err)
+	}
+}
+
+// Extracts the root window of each positive example
+// after aligning and resizing it to the detection window.
+func warpPos(pos []Pos, opts TrainOpts) ([][]float64, error) {
+	shape := opts.PixelShape
+	size := opts.Transform.Size(shape.Size)
+	var vecs [][]float64
+	for i, p := range pos {
+		_, fit := detect.FitRect(p.Rect, shape, opts.AspectMode)
+		crop := imsamp.Rect(p.Image, fit, imsamp.Continue)
+		warp := resize.Resize(uint(shape.Size.X), uint(shape.Size.Y), crop, opts.Interp)
+		x, err := opts.Transform.Apply(warp)
+		if err != nil {
+			return nil, err
+		}
+		if !x.Size().Eq(size) {
+			return nil, fmt.Errorf("positive %d: wrong feature size: want %v, got %v", i, size, x.Size())
+		}
+		vecs = append(vecs, append(x.Elems, opts.BiasFeat))
+	}
+	return vecs, nil
+}
+
+// Extracts random root windows from a negative image at the original scale.
+func randNeg(im image.Image, model *Model, opts TrainOpts, r *rand.Rand) ([][]float64, error) {
+	x, err := feat.ApplyPad(opts.Transform, im, opts.Pad)
+	if err != nil {
+		return nil, err
+	}
+	size := model.Size()
+	if x.Width < size.X || x.Height < size.Y {
+		return nil, nil
+	}
+	vecs := make([][]float64, opts.InitNegPerImage)
+	for i := range vecs {
+		pt := image.Pt(r.Intn(x.Width-size.X+1), r.Intn(x.Height-size.Y+1))
+		vecs[i] = model.featVec(x, nil, pt, image.ZP, nil, opts.BiasFeat)
+	}
+	return vecs, nil
+}
+
+// Window in the pyramid at which a model was evaluated.
+type placement struct {
+	Score      float64
+	Root, Part *featpyr.Level
+	Pos        image.Point
+	Parts      []image.Point
+}
+
+func (p *placement) featVec(model *Model, offset image.Point, biasFeat float64) []float64 {
+	var parts *rimg64.Multi
+	if p.Part != nil {
+		parts = p.Part.Feat
+	}
+	return model.featVec(p.Root.Feat, parts, p.Pos, offset, p.Parts, biasFeat)
+}
+
+// Finds the highest-scoring placement of the model on every positive example
+// which overlaps the bounding box.
+// Examples without such a placement are skipped.
+func relabel(pos []Pos, model *Model, opts TrainOpts) ([][]float64, error) {
+	offset, err := partOffset(opts.pyrOpts(1))
+	if err != nil {
+		return nil, err
+	}
+	var vecs [][]float64
+	for _, p := range pos {
+		best, err := latentPos(p, model, opts)
+		if err != nil {
+			return nil, err
+		}
+		if best == nil {
+			continue
+		}
+		vecs = append(vecs, best.featVec(model, offset, opts.BiasFeat))
+	}
+	return vecs, nil
+}
+
+// Finds the highest-scoring placement which overlaps the bounding box.
+// Returns nil if there is none.
+func latentPos(p Pos, model *Model, opts TrainOpts) (*placement, error) {
+	shape := opts.PixelShape
+	scale, fit := detect.FitRect(p.Rect, shape, opts.AspectMode)
+	// Search a region around the aligned window.
+	margin := fit.Size().Div(2)
+	region := image.Rectangle{fit.Min.Sub(margin), fit.Max.Add(margin)}
+	crop := imsamp.Rect(p.Image, region, imsamp.Continue)
+	box := p.Rect.Sub(region.Min)
+
+	// Start the pyramid one step above the scale of the example.
+	step := opts.PyrStep
+	if step < 1 {
+		step = 1 / step
+	}
+	maxScale := scale * step
+	if len(model.Parts) > 0 {
+		maxScale *= 2
+	}
+
+	var best *placement
+	err := walk(crop, model, opts.pyrOpts(maxScale), func(pyr *featpyr.Generator, root, part *featpyr.Level, resp *Response) error {
+		for x := 0; x < resp.Score.Width; x++ {
+			for y := 0; y < resp.Score.Height; y++ {
+				score := resp.Score.At(x, y)
+				if best != nil && score <= best.Score {
+					continue
+				}
+				rect := pyr.ToImageRect(root.Image.Index, image.Pt(x, y), shape.Int)
+				if detect.IOU(rect, box) < opts.MinIOU {
+					continue
+				}
+				best = &placement{score, root, part, image.Pt(x, y), resp.Placement(x, y)}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return best, nil
+}
+
+// Adds the hardest windows in a negative image to the cache.
+func mineImage(cache *detect.NegCache, index int, im image.Image, model *Model, opts TrainOpts) error {
+	pyropts := opts.pyrOpts(opts.MaxScale)
+	offset, err := partOffset(pyropts)
+	if err != nil {
+		return err
+	}
+	var cands []*placement
+	err = walk(im, model, pyropts, func(pyr *featpyr.Generator, root, part *featpyr.Level, resp *Response) error {
+		pts := detect.RespPoints(resp.Score, true, -negMargin)
+		for _, pt := range pts {
+			cands = append(cands, &placement{pt.Score, root, part, pt.Point, resp.Placement(pt.X, pt.Y)})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Sort(placementsByScoreDesc(cands))
+	if opts.NegPerImage > 0 && len(cands) > opts.NegPerImage {
+		cands = cands[:opts.NegPerImage]
+	}
+	for _, c := range cands {
+		pt := imgpyr.Point{Level: c.Root.Image.Index, Pos: c.Pos}
+		cache.Add(negWindow(index, pt, c.featVec(model, offset, opts.BiasFeat), c.Score))
+	}
+	return nil
+}
+
+type placementsByScoreDesc []*placement
+
+func (s placementsByScoreDesc) Len() int           { return len(s) }
+func (s placementsByScoreDesc) Less(i, j int) bool { return s[i].Score > s[j].Score }
+func (s placementsByScoreDesc) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Stores the feature vector of a negative example in a window
+// which can be kept in a detect.NegCache.
+// Random windows have level -1 and are numbered by Pos.X.
+func negWindow(index int, pt imgpyr.Point, x []float64, score float64) *detect.NegWindow {
+	feat := &rimg64.Multi{Elems: x, Width: len(x), Height: 1, Channels: 1}
+	return &detect.NegWindow{Image: index, Point: pt, Score: score, Feat: feat}
+}
+
+// Scores the feature vector of a negative example.
+type vecScorer []float64
+
+func (w vecScorer) Size() image.Point { return image.Pt(len(w), 1) }
+
+func (w vecScorer) Score(x *rimg64.Multi) (float64, error) {
+	if len(x.Elems) != len(w) {
+		return 0, fmt.Errorf("different length: vector %d, weights %d", len(x.Elems), len(w))
+	}
+	return dot(w, x.Elems), nil
+}
+
+// Initializes parts by choosing the regions of highest energy
+// in the root template after upsampling by a factor of two.
+func initParts(model *Model, num int, size image.Point) error {
+	root := model.Root.Tmpl
+	up := rimg64.NewMulti(2*root.Width, 2*root.Height, root.Channels)
+	for x := 0; x < up.Width; x++ {
+		for y := 0; y < up.Height; y++ {
+			for k := 0; k < up.Channels; k++ {
+				up.Set(x, y, k, root.At(x/2, y/2, k))
+			}
+		}
+	}
+	if size.X > up.Width || size.Y > up.Height {
+		return fmt.Errorf("part size %v exceeds upsampled root %v", size, up.Size())
+	}
+	// Energy of positive weights in each cell.
+	energy := rimg64.New(up.Width, up.Height)
+	for x := 0; x < up.Width; x++ {
+		for y := 0; y < up.Height; y++ {
+			var e float64
+			for k := 0; k < up.Channels; k++ {
+				w := math.Max(up.At(x, y, k), 0)
+				e += w * w
+			}
+			energy.Set(x, y, e)
+		}
+	}
+	ones := rimg64.New(size.X, size.Y)
+	for i := range ones.Elems {
+		ones.Elems[i] = 1
+	}
+	for i := 0; i < num; i++ {
+		// Sum of energy in every window.
+		sum, err := slide.CorrNaive(energy, ones)
+		if err != nil {
+			return err
+		}
+		var (
+			best image.Point
+			max  = math.Inf(-1)
+		)
+		for x := 0; x < sum.Width; x++ {
+			for y := 0; y < sum.Height; y++ {
+				if sum.At(x, y) > max {
+					best, max = image.Pt(x, y), sum.At(x, y)
+				}
+			}
+		}
+		rect := image.Rectangle{best, best.Add(size)}
+		model.Parts = append(model.Parts, &Part{
+			Scorer: &slide.AffineScorer{Tmpl: up.SubImage(rect)},
+			Anchor: best,
+			Cost:   initCost,
+		})
+		// Do not place another part here.
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				energy.Set(x, y, 0)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package dpm_test
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/detect/batch"
+	"github.com/jvlmdr/go-cv/dpm"
+	"github.com/jvlmdr/go-cv/feat"
+	"github.com/jvlmdr/go-cv/featset"
+	"github.com/jvlmdr/go-cv/imsamp"
+	"github.com/nfnt/resize"
+)
+
+// Generates a noisy image containing a bright ring.
+// Returns the image and the bounding box of the ring.
+func ringImage(size, obj int, r *rand.Rand) (image.Image, image.Rectangle) {
+	im := noiseImage(size, r)
+	pos := image.Pt(r.Intn(size-obj), r.Intn(size-obj))
+	box := image.Rectangle{pos, pos.Add(image.Pt(obj, obj))}
+	inner := box.Inset(obj / 4)
+	for x := box.Min.X; x < box.Max.X; x++ {
+		for y := box.Min.Y; y < box.Max.Y; y++ {
+			if !image.Pt(x, y).In(inner) {
+				im.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return im, box
+}
+
+func noiseImage(size int, r *rand.Rand) *image.Gray {
+	im := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range im.Pix {
+		im.Pix[i] = uint8(r.Intn(128))
+	}
+	return im
+}
+
+func TestTrain(t *testing.T) {
+	const (
+		size = 40
+		obj  = 16
+	)
+	r := rand.New(rand.NewSource(1))
+	var pos []dpm.Pos
+	for i := 0; i < 12; i++ {
+		im, box := ringImage(size, obj, r)
+		pos = append(pos, dpm.Pos{im, box})
+	}
+	var neg []image.Image
+	for i := 0; i < 6; i++ {
+		neg = append(neg, noiseImage(size, r))
+	}
+
+	shape := detect.PadRect{Size: image.Pt(12, 12), Int: image.Rect(2, 2, 10, 10)}
+	opts := dpm.TrainOpts{
+		PixelShape:      shape,
+		AspectMode:      "area",
+		Transform:       new(featset.Gray),
+		Pad:             feat.Pad{feat.UniformMargin(2), imsamp.Continue},
+		MaxScale:        1,
+		PyrStep:         1.4142135623730951,
+		Interp:          resize.Bilinear,
+		NumParts:        2,
+		PartSize:        image.Pt(6, 6),
+		C:               1,
+		BiasFeat:        1,
+		Epochs:          10,
+		LatentRounds:    2,
+		MiningRounds:    1,
+		MinIOU:          0.7,
+		InitNegPerImage: 10,
+		NegPerImage:     20,
+		CacheSize:       200,
+		Seed:            1,
+	}
+	tmpl, err := dpm.Train(pos, neg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(tmpl.Model.Parts); n != opts.NumParts {
+		t.Fatalf("wrong number of parts: want %d, got %d", opts.NumParts, n)
+	}
+	for i, part := range tmpl.Model.Parts {
+		if part.Cost.XX < 0.01 || part.Cost.YY < 0.01 {
+			t.Errorf("part %d: quadratic cost below bound: %+v", i, part.Cost)
+		}
+	}
+
+	// Model must survive serialization.
+	data, err := json.Marshal(tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec dpm.Tmpl
+	if err := json.Unmarshal(data, &dec); err != nil {
+		t.Fatal(err)
+	}
+
+	// Best detection should find the object.
+	detopts := detect.MultiScaleOpts{
+		MaxScale:    2,
+		PyrStep:     opts.PyrStep,
+		Interp:      opts.Interp,
+		Transform:   opts.Transform,
+		Pad:         opts.Pad,
+		DetFilter:   detect.DetFilter{LocalMax: true, MinScore: -100},
+		SupprFilter: detect.SupprFilter{MaxNum: 1, Overlap: func(a, b image.Rectangle) bool { return detect.IOU(a, b) > 0.3 }},
+	}
+	var det batch.Detector = &dpm.Detector{Tmpl: &dec, Opts: detopts}
+	var found int
+	const trials = 5
+	for i := 0; i < trials; i++ {
+		im, box := ringImage(size, obj, r)
+		dets, err := dpm.MultiScale(im, dec.Model, dec.PixelShape, detopts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots, err := det.Detect(im, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dets) == 0 {
+			continue
+		}
+		if len(roots) != len(dets) || roots[0] != dets[0].Det {
+			t.Fatalf("detector: different detections to MultiScale")
+		}
+		if len(dets[0].Parts) != opts.NumParts {
+			t.Fatalf("wrong number of part rectangles: want %d, got %d", opts.NumParts, len(dets[0].Parts))
+		}
+		if detect.IOU(dets[0].Rect, box) >= 0.5 {
+			found++
+		}
+	}
+	if found < trials-1 {
+		t.Errorf("found %d of %d objects", found, trials)
+	}
+}
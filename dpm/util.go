@@ -0,0 +1,8 @@
+package dpm
+
+func max(a, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
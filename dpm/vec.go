@@ -0,0 +1,95 @@
+package dpm
+
+import (
+	"image"
+
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+// The parameters of a model are concatenated into one vector:
+// the root template, then the template and deformation cost of each part,
+// and finally the bias.
+// Only the bias of the root is used.
+//
+// The feature vector of a root position and part placement
+// has the same layout, such that its score is the inner product.
+// The deformation features of displacement (dx, dy) are
+// 	(-dx^2, -dx, -dy^2, -dy)
+// and the bias feature is a constant.
+
+// Number of deformation parameters per part.
+const defLen = 4
+
+// Length of the parameter vector.
+func (m *Model) vecLen() int {
+	n := len(m.Root.Tmpl.Elems)
+	for _, part := range m.Parts {
+		n += len(part.Scorer.Tmpl.Elems) + defLen
+	}
+	return n + 1
+}
+
+// Returns the parameter vector of the model.
+// The bias of the root is divided by biasFeat.
+func (m *Model) vec(biasFeat float64) []float64 {
+	w := make([]float64, 0, m.vecLen())
+	w = append(w, m.Root.Tmpl.Elems...)
+	for _, part := range m.Parts {
+		w = append(w, part.Scorer.Tmpl.Elems...)
+		c := part.Cost
+		w = append(w, c.XX, c.X, c.YY, c.Y)
+	}
+	var b float64
+	if biasFeat != 0 {
+		b = m.Root.Bias / biasFeat
+	}
+	return append(w, b)
+}
+
+// Sets the parameters of the model from a vector.
+func (m *Model) setVec(w []float64, biasFeat float64) {
+	if len(w) != m.vecLen() {
+		panic("wrong vector length")
+	}
+	n := copy(m.Root.Tmpl.Elems, w)
+	w = w[n:]
+	for _, part := range m.Parts {
+		part.Scorer.Bias = 0
+		n := copy(part.Scorer.Tmpl.Elems, w)
+		w = w[n:]
+		part.Cost = QuadCost{w[0], w[1], w[2], w[3]}
+		w = w[defLen:]
+	}
+	m.Root.Bias = w[0] * biasFeat
+}
+
+// Returns the indices of the quadratic deformation coefficients.
+func (m *Model) quadIndices() []int {
+	var inds []int
+	n := len(m.Root.Tmpl.Elems)
+	for _, part := range m.Parts {
+		n += len(part.Scorer.Tmpl.Elems)
+		inds = append(inds, n, n+2)
+		n += defLen
+	}
+	return inds
+}
+
+// Constructs the feature vector of a root position and part placement.
+// The root window has its top-left corner at pt in the root features.
+// The part windows have their top-left corners at place in the part features.
+func (m *Model) featVec(root, parts *rimg64.Multi, pt, offset image.Point, place []image.Point, biasFeat float64) []float64 {
+	x := make([]float64, 0, m.vecLen())
+	size := m.Root.Size()
+	x = append(x, root.SubImage(image.Rectangle{pt, pt.Add(size)}).Elems...)
+	for i, part := range m.Parts {
+		q := place[i]
+		size := part.Scorer.Size()
+		x = append(x, parts.SubImage(image.Rectangle{q, q.Add(size)}).Elems...)
+		anchor := pt.Mul(2).Add(offset).Add(part.Anchor)
+		d := q.Sub(anchor)
+		dx, dy := float64(d.X), float64(d.Y)
+		x = append(x, -dx*dx, -dx, -dy*dy, -dy)
+	}
+	return append(x, biasFeat)
+}
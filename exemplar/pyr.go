@@ -1,17 +1,54 @@
 package exemplar
 
 import (
+	"errors"
 	"image"
+	"sort"
 
 	"github.com/jvlmdr/go-cv/detect"
 	"github.com/jvlmdr/go-cv/featpyr"
 	"github.com/jvlmdr/go-cv/imgpyr"
+	"github.com/jvlmdr/go-cv/slide"
 )
 
+// MultiScale evaluates every template at every level of the pyramid.
+// The feature image at each level is shared by all templates
+// using slide.AffineList.
+// Templates are evaluated in order of their key
+// so that the result does not depend on map iteration order.
+//
+// The scores of templates which have a calibration are mapped
+// before non-max suppression.
+// The threshold in DetFilter is applied to the raw score.
+//
+// Greedy non-max suppression is always performed using SupprFilter.
+// An error is returned if Suppressor or DetFilter.ScaleMax is set.
+// Workers, Progress and Metrics are ignored.
 func MultiScale(im image.Image, tmpls map[string]*detect.FeatTmpl, opts detect.MultiScaleOpts) ([]Det, error) {
+	if opts.Suppressor != nil {
+		return nil, errors.New("suppressor is not supported")
+	}
+	if opts.DetFilter.ScaleMax {
+		return nil, errors.New("maxima over scale are not supported")
+	}
 	if len(tmpls) == 0 {
 		return nil, nil
 	}
+	keys := make([]string, 0, len(tmpls))
+	for key := range tmpls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	scorers := make([]*slide.AffineScorer, len(keys))
+	for i, key := range keys {
+		scorers[i] = tmpls[key].Scorer
+	}
+	list, err := slide.NewAffineList(scorers)
+	if err != nil {
+		return nil, err
+	}
+	defer list.Destroy()
+
 	scales := imgpyr.Scales(im.Bounds().Size(), minDims(tmpls), opts.MaxScale, opts.PyrStep).Elems()
 	ims := imgpyr.NewGenerator(im, scales, opts.Interp)
 	pyr := featpyr.NewGenerator(ims, opts.Transform, opts.Pad)
@@ -21,15 +58,23 @@ func MultiScale(im image.Image, tmpls map[string]*detect.FeatTmpl, opts detect.M
 		return nil, err
 	}
 	for l != nil {
-		for key, tmpl := range tmpls {
-			pts := detect.Points(l.Feat, tmpl.Image, tmpl.Bias, opts.DetFilter.LocalMax, opts.DetFilter.MinScore)
+		resps, err := list.Slide(l.Feat)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range keys {
+			pts := detect.RespPoints(resps[i], opts.DetFilter.LocalMax, opts.DetFilter.MinScore)
+			calib := tmpls[key].Calib
 			// Convert to scored rectangles in the image.
 			for _, pt := range pts {
-				rect := pyr.ToImageRect(l.Image.Index, pt.Point, tmpl.Interior)
-				dets = append(dets, Det{detect.Det{pt.Score + tmpl.Bias, rect}, key})
+				rect := pyr.ToImageRect(l.Image.Index, pt.Point, tmpls[key].PixelShape.Int)
+				score := pt.Score
+				if calib != nil {
+					score = calib.Calibrate(score)
+				}
+				dets = append(dets, Det{detect.Det{score, rect}, key})
 			}
 		}
-		var err error
 		l, err = pyr.Next(l)
 		if err != nil {
 			return nil, err
@@ -55,15 +100,16 @@ func minDims(tmpls map[string]*detect.FeatTmpl) image.Point {
 		init bool
 	)
 	for _, tmpl := range tmpls {
+		size := tmpl.Scorer.Size()
 		if !init {
-			x, y, init = tmpl.Size.X, tmpl.Size.Y, true
+			x, y, init = size.X, size.Y, true
 			continue
 		}
-		if tmpl.Size.X < x {
-			x = tmpl.Size.X
+		if size.X < x {
+			x = size.X
 		}
-		if tmpl.Size.Y < y {
-			y = tmpl.Size.Y
+		if size.Y < y {
+			y = size.Y
 		}
 	}
 	return image.Pt(x, y)
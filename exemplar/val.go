@@ -1,6 +1,7 @@
 package exemplar
 
 import (
+	"fmt"
 	"image"
 
 	"github.com/jvlmdr/go-cv/detect"
@@ -26,9 +27,12 @@ func Validate(dets []Det, refs, ignore []image.Rectangle, refMinIOU, ignoreMinCo
 }
 
 func (im *ValImage) Scores() []detect.ValScore {
-	scores := make([]detect.ValScore, len(im.Dets))
-	for i, det := range im.Dets {
-		scores[i] = detect.ValScore{det.Score, det.True}
+	scores := make([]detect.ValScore, 0, len(im.Dets))
+	for _, det := range im.Dets {
+		if det.Ignore {
+			continue
+		}
+		scores = append(scores, detect.ValScore{det.Score, det.True})
 	}
 	return scores
 }
@@ -36,3 +40,44 @@ func (im *ValImage) Scores() []detect.ValScore {
 func (im *ValImage) Set() *detect.ValSet {
 	return &detect.ValSet{im.Scores(), len(im.Misses), 1}
 }
+
+// TmplSets splits the validated detections in a set of images by template.
+// Every template is assumed to have been evaluated in every image,
+// therefore Images is the number of images.
+// Missed instances cannot be attributed to a template,
+// therefore Misses is zero and miss rates cannot be computed from the sets.
+func TmplSets(ims []*ValImage) map[string]*detect.ValSet {
+	byTmpl := make(map[string][]detect.ValScore)
+	for _, im := range ims {
+		for _, det := range im.Dets {
+			if det.Ignore {
+				continue
+			}
+			byTmpl[det.Tmpl] = append(byTmpl[det.Tmpl], detect.ValScore{det.Score, det.True})
+		}
+	}
+	sets := make(map[string]*detect.ValSet, len(byTmpl))
+	for tmpl, dets := range byTmpl {
+		// Sorts the detections.
+		sets[tmpl] = detect.MergeValSets(&detect.ValSet{Dets: dets, Images: len(ims)})
+	}
+	return sets
+}
+
+// Calibrate fits a calibration to each template using the named method
+// ("platt" or "isotonic") and assigns it to the template.
+// Templates without validated detections are not modified.
+func Calibrate(tmpls map[string]*detect.FeatTmpl, sets map[string]*detect.ValSet, method string) error {
+	for key, tmpl := range tmpls {
+		set, ok := sets[key]
+		if !ok || len(set.Dets) == 0 {
+			continue
+		}
+		calib, err := detect.FitCalib(set, method)
+		if err != nil {
+			return fmt.Errorf("template %s: %v", key, err)
+		}
+		tmpl.Calib = calib
+	}
+	return nil
+}
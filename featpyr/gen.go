@@ -1,11 +1,14 @@
 package featpyr
 
 import (
+	"context"
 	"image"
+	"sync"
 	"time"
 
 	"github.com/jvlmdr/go-cv/feat"
 	"github.com/jvlmdr/go-cv/imgpyr"
+	"github.com/jvlmdr/go-cv/metrics"
 	"github.com/jvlmdr/go-cv/rimg64"
 )
 
@@ -16,9 +19,15 @@ type Generator struct {
 	Image     *imgpyr.Generator
 	Transform feat.Image
 	feat.Pad
-	// Cumulative time.
-	DurResize time.Duration
-	DurFeat   time.Duration
+	// If not nil, called after each level is computed.
+	// Calls are not concurrent.
+	Progress Progress
+	// If not nil, receives the time and size of the resize
+	// and feature stages at each level.
+	Metrics metrics.Recorder
+	// Guards the progress in ForEach.
+	mu   sync.Mutex
+	done int
 }
 
 func NewGenerator(im *imgpyr.Generator, phi feat.Image, pad feat.Pad) *Generator {
@@ -31,35 +40,132 @@ type Level struct {
 	Feat  *rimg64.Multi
 }
 
+// First computes the first level.
+// The progress is reset.
 func (pyr *Generator) First() (*Level, error) {
+	return pyr.FirstContext(context.Background())
+}
+
+// FirstContext is like First but returns the error of the context if it is done.
+func (pyr *Generator) FirstContext(ctx context.Context) (*Level, error) {
+	pyr.mu.Lock()
+	pyr.done = 0
+	pyr.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	t := time.Now()
 	im := pyr.Image.First()
-	pyr.DurResize = time.Since(t)
 	if im == nil {
 		return nil, nil
 	}
-	t = time.Now()
-	x, err := feat.ApplyPad(pyr.Transform, im.Image, pyr.Pad)
-	pyr.DurFeat = time.Since(t)
-	if err != nil {
-		return nil, err
-	}
-	return &Level{im, x}, nil
+	return pyr.apply(im, time.Since(t))
 }
 
+// Next computes the level after the current one.
+// Returns nil if the current level was the last.
 func (pyr *Generator) Next(curr *Level) (*Level, error) {
+	return pyr.NextContext(context.Background(), curr)
+}
+
+// NextContext is like Next but returns the error of the context if it is done.
+func (pyr *Generator) NextContext(ctx context.Context, curr *Level) (*Level, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	t := time.Now()
 	im := pyr.Image.Next(curr.Image)
-	pyr.DurResize += time.Since(t)
 	if im == nil {
 		return nil, nil
 	}
-	t = time.Now()
+	return pyr.apply(im, time.Since(t))
+}
+
+// ForEach computes every level using up to n workers and calls visit with each.
+// The images are rescaled in order, exactly as by First and Next,
+// so the levels do not depend on n.
+// The features are computed and visit is called concurrently,
+// therefore Transform.Apply and visit must be safe for concurrent use.
+// Returns the error of the context if it is done before every level is visited,
+// otherwise the error of the first level which failed.
+// The progress is reset.
+func (pyr *Generator) ForEach(ctx context.Context, n int, visit func(*Level) error) error {
+	pyr.mu.Lock()
+	pyr.done = 0
+	pyr.mu.Unlock()
+	if n < 1 {
+		n = 1
+	}
+	type job struct {
+		im        *imgpyr.Level
+		durResize time.Duration
+	}
+	num := len(pyr.Image.Scales)
+	var (
+		errs = make([]error, num)
+		jobs = make(chan job)
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < n && w < num; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				i := j.im.Index
+				if errs[i] = ctx.Err(); errs[i] != nil {
+					continue
+				}
+				l, err := pyr.apply(j.im, j.durResize)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = visit(l)
+			}
+		}()
+	}
+	t := time.Now()
+	for im := pyr.Image.First(); im != nil && ctx.Err() == nil; im = pyr.Image.Next(im) {
+		jobs <- job{im, time.Since(t)}
+		t = time.Now()
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pyr *Generator) apply(im *imgpyr.Level, durResize time.Duration) (*Level, error) {
+	metrics.Record(pyr.Metrics, metrics.Event{
+		Stage: metrics.Resize,
+		Level: im.Index,
+		Dur:   durResize,
+		Size:  im.Image.Bounds().Size(),
+	})
+	t := time.Now()
 	x, err := feat.ApplyPad(pyr.Transform, im.Image, pyr.Pad)
-	pyr.DurFeat += time.Since(t)
 	if err != nil {
 		return nil, err
 	}
+	metrics.Record(pyr.Metrics, metrics.Event{
+		Stage: metrics.Feat,
+		Level: im.Index,
+		Dur:   time.Since(t),
+		Size:  x.Size(),
+	})
+	pyr.mu.Lock()
+	pyr.done++
+	if pyr.Progress != nil {
+		pyr.Progress(pyr.done, len(pyr.Image.Scales))
+	}
+	pyr.mu.Unlock()
 	return &Level{im, x}, nil
 }
 
@@ -72,3 +178,11 @@ func (pyr *Generator) ToImageRect(level int, pt image.Point, interior image.Rect
 	rect := interior.Add(pt.Mul(rate)).Sub(offset)
 	return scaleRect(1/scale, rect)
 }
+
+// ToLevel maps a position in the feature image of one level
+// to the corresponding position in another level.
+// The position is converted to image coordinates and back.
+func (pyr *Generator) ToLevel(from, to int, x, y float64) (float64, float64) {
+	scales := pyr.Image.Scales
+	return toLevel(pyr.Transform.Rate(), pyr.Pad.Margin, scales[from], scales[to], x, y)
+}
@@ -1,8 +1,8 @@
 package featpyr
 
 import (
+	"context"
 	"image"
-	"log"
 
 	"github.com/jvlmdr/go-cv/feat"
 	"github.com/jvlmdr/go-cv/imgpyr"
@@ -21,19 +21,34 @@ type Pyramid struct {
 	Margin feat.Margin
 }
 
+// Progress is called after each level of a pyramid is computed
+// with the number of levels which are done and the total number.
+type Progress func(done, total int)
+
 // Constructs a feature pyramid.
 // Extends each level by a margin before computing features.
 func NewPad(images *imgpyr.Pyramid, phi feat.Image, pad feat.Pad) (*Pyramid, error) {
+	return NewPadContext(context.Background(), images, phi, pad, nil)
+}
+
+// NewPadContext is like NewPad but returns the error of the context
+// if it is done before every level has been computed.
+// If progress is not nil, it is called after each level.
+func NewPadContext(ctx context.Context, images *imgpyr.Pyramid, phi feat.Image, pad feat.Pad, progress Progress) (*Pyramid, error) {
 	feats := make([]*rimg64.Multi, len(images.Levels))
 	for i, im := range images.Levels {
-		var err error
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		f, err := feat.ApplyPad(phi, im, pad)
 		if err != nil {
 			return nil, err
 		}
 		feats[i] = f
+		if progress != nil {
+			progress(i+1, len(feats))
+		}
 	}
-	log.Print("finished computing feature transform: ", len(images.Levels))
 	return &Pyramid{images, feats, phi.Rate(), pad.Margin}, nil
 }
 
@@ -65,3 +80,17 @@ func (pyr *Pyramid) ToImageRect(pt imgpyr.Point, interior image.Rectangle) image
 	// Scale rectangle.
 	return scaleRect(1/pyr.Scale(pt.Level), rect)
 }
+
+// ToLevel maps a position in the feature image of one level
+// to the corresponding position in another level.
+// The position is converted to image coordinates and back.
+func (pyr *Pyramid) ToLevel(from, to int, x, y float64) (float64, float64) {
+	return toLevel(pyr.Rate, pyr.Margin, pyr.Scale(from), pyr.Scale(to), x, y)
+}
+
+func toLevel(rate int, margin feat.Margin, from, to float64, x, y float64) (float64, float64) {
+	off := vec(margin.TopLeft())
+	p := vector{x, y}.Mul(float64(rate)).Sub(off)
+	p = p.Mul(to / from).Add(off).Div(float64(rate))
+	return p.X, p.Y
+}
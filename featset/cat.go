@@ -1,152 +1,306 @@
 package featset
 
-//	import (
-//		"errors"
-//		"fmt"
-//		"image"
-//
-//		"github.com/jvlmdr/go-cv/rimg64"
-//	)
-//
-//	func init() {
-//		RegisterReal("concat", newConcatMarshaler)
-//	}
-//
-//	type RealList interface {
-//		Len() int
-//		At(int) Real
-//	}
-//
-//	type RealSlice []Real
-//
-//	func (fs RealSlice) Len() int      { return len(fs) }
-//	func (fs RealSlice) At(i int) Real { return fs[i] }
-//
-//	// Need a concrete slice to unmarshal unknown number.
-//	// Could use a linked list instead but it might be messier.
-//	type realMarshalerSlice []*RealMarshaler
-//
-//	func (fs realMarshalerSlice) Len() int      { return len(fs) }
-//	func (fs realMarshalerSlice) At(i int) Real { return fs[i] }
-//
-//	func newConcatMarshaler() Real {
-//		return &Concat{realMarshalerSlice{}}
-//	}
-//
-//	// Concat concatenates the channels of a collection of transforms.
-//	// Each transform must output the same size and have the same rate.
-//	type Concat struct {
-//		Elems RealList
-//	}
-//
-//	func (phi *Concat) Rate() int {
-//		var rate int
-//		for i := 0; i < phi.Elems.Len(); i++ {
-//			elem := phi.Elems.At(i)
-//			if i == 0 {
-//				rate = elem.Rate()
-//				continue
-//			}
-//			if elem.Rate() != rate {
-//				panic("transforms have different rates")
-//			}
-//		}
-//		return rate
-//	}
-//
-//	func (phi *Concat) Apply(x *rimg64.Multi) (*rimg64.Multi, error) {
-//		ys := make([]*rimg64.Multi, phi.Elems.Len())
-//		for i := 0; i < phi.Elems.Len(); i++ {
-//			elem := phi.Elems.At(i)
-//			// Execute each transform.
-//			y, err := elem.Apply(x)
-//			if err != nil {
-//				return nil, err
-//			}
-//			ys[i] = y
-//		}
-//		// If all nil, then return nil.
-//		allNil := true
-//		for _, y := range ys {
-//			if y != nil {
-//				allNil = false
-//				break
-//			}
-//		}
-//		if allNil {
-//			return nil, nil
-//		}
-//		// Check that sizes are all the same.
-//		var size image.Point
-//		for i, y := range ys {
-//			if y == nil {
-//				return nil, errors.New("some transforms are nil")
-//			}
-//			if i == 0 {
-//				size = y.Size()
-//				continue
-//			}
-//			if !y.Size().Eq(size) {
-//				return nil, fmt.Errorf("different image sizes: %v, %v", size, y.Size())
-//			}
-//		}
-//		var channels int
-//		for _, y := range ys {
-//			channels += y.Channels
-//		}
-//		// Copy into one image.
-//		z := rimg64.NewMulti(size.X, size.Y, channels)
-//		q := 0
-//		for _, y := range ys {
-//			for u := 0; u < size.X; u++ {
-//				for v := 0; v < size.Y; v++ {
-//					for p := 0; p < y.Channels; p++ {
-//						z.Set(u, v, q+p, y.At(u, v, p))
-//					}
-//				}
-//			}
-//			q += y.Channels
-//		}
-//		return z, nil
-//	}
-//
-//	func (phi *Concat) Size(x image.Point) image.Point {
-//		var size image.Point
-//		for i := 0; i < phi.Elems.Len(); i++ {
-//			curr := phi.Elems.At(i).Size(x)
-//			if i == 0 {
-//				size = curr
-//				continue
-//			}
-//			if !size.Eq(curr) {
-//				panic(fmt.Sprintf("different image sizes: %v, %v", size, curr))
-//			}
-//		}
-//		return size
-//	}
-//
-//	func (phi *Concat) Channels() int {
-//		var n int
-//		for i := 0; i < phi.Elems.Len(); i++ {
-//			n += phi.Elems.At(i).Channels()
-//		}
-//		return n
-//	}
-//
-//	func (phi *Concat) Marshaler() *RealMarshaler {
-//		// Obtain marshaler for each member.
-//		ms := make([]*RealMarshaler, phi.Elems.Len())
-//		for i := 0; i < phi.Elems.Len(); i++ {
-//			ms[i] = phi.Elems.At(i).Marshaler()
-//		}
-//		return &RealMarshaler{"concat", &Concat{realMarshalerSlice(ms)}}
-//	}
-//
-//	func (phi *Concat) Transform() Real {
-//		// Obtain marshaler for each member.
-//		fs := make([]Real, phi.Elems.Len())
-//		for i := 0; i < phi.Elems.Len(); i++ {
-//			fs[i] = phi.Elems.At(i).Transform()
-//		}
-//		return &Concat{RealSlice(fs)}
-//	}
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+func init() {
+	RegisterReal("concat", func() Real { return new(Concat) })
+	RegisterImage("concat-image", func() Image { return new(ConcatImage) })
+}
+
+// Concat concatenates the channels of a collection of transforms.
+// Each transform must have the same rate.
+// If the transforms produce images of different sizes,
+// then each is cropped to the largest size which all attain.
+// Feature images are cropped on the bottom and right,
+// which assumes that position (0, 0) of every transform corresponds to
+// the same window at the top-left corner of the input.
+// This requires that the transforms have the same rate and the same border:
+// for example, valid convolutions with kernels of different sizes
+// are aligned at the corner of their kernels, not the center.
+// The rate is checked by Apply and UnmarshalJSON, the border is not.
+type Concat struct {
+	Elems []Real
+}
+
+func (phi *Concat) Rate() int {
+	rate, err := realRate(phi.Elems)
+	if err != nil {
+		panic(err)
+	}
+	return rate
+}
+
+func (phi *Concat) Apply(x *rimg64.Multi) (*rimg64.Multi, error) {
+	if _, err := realRate(phi.Elems); err != nil {
+		return nil, err
+	}
+	ys := make([]*rimg64.Multi, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		// Execute each transform.
+		y, err := elem.Apply(x)
+		if err != nil {
+			return nil, err
+		}
+		ys[i] = y
+	}
+	return concat(ys)
+}
+
+func (phi *Concat) Size(x image.Point) image.Point {
+	sizes := make([]image.Point, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		sizes[i] = elem.Size(x)
+	}
+	return minSize(sizes)
+}
+
+func (phi *Concat) MinInputSize(y image.Point) image.Point {
+	sizes := make([]image.Point, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		sizes[i] = elem.MinInputSize(y)
+	}
+	return maxSize(sizes)
+}
+
+func (phi *Concat) Channels() int {
+	var n int
+	for _, elem := range phi.Elems {
+		n += elem.Channels()
+	}
+	return n
+}
+
+func (phi *Concat) Marshaler() *RealMarshaler {
+	// Obtain marshaler for each member.
+	ms := make([]Real, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		ms[i] = elem.Marshaler()
+	}
+	return &RealMarshaler{"concat", &Concat{ms}}
+}
+
+func (phi *Concat) Transform() Real {
+	fs := make([]Real, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		fs[i] = elem.Transform()
+	}
+	return &Concat{fs}
+}
+
+// UnmarshalJSON decodes each member using a RealMarshaler.
+// This is necessary because the members are interfaces.
+func (phi *Concat) UnmarshalJSON(data []byte) error {
+	var x struct{ Elems []*RealMarshaler }
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	phi.Elems = make([]Real, len(x.Elems))
+	for i, m := range x.Elems {
+		if m == nil {
+			return fmt.Errorf("concat: element %d is null", i)
+		}
+		phi.Elems[i] = m
+	}
+	if len(phi.Elems) > 0 {
+		if _, err := realRate(phi.Elems); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConcatImage concatenates the channels of a collection of transforms.
+// It behaves like Concat except that each member
+// is computed directly on the integer-valued image.
+// The members must have the same rate and border as in Concat.
+type ConcatImage struct {
+	Elems []Image
+}
+
+func (phi *ConcatImage) Rate() int {
+	rate, err := imageRate(phi.Elems)
+	if err != nil {
+		panic(err)
+	}
+	return rate
+}
+
+func (phi *ConcatImage) Apply(im image.Image) (*rimg64.Multi, error) {
+	if _, err := imageRate(phi.Elems); err != nil {
+		return nil, err
+	}
+	ys := make([]*rimg64.Multi, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		// Execute each transform.
+		y, err := elem.Apply(im)
+		if err != nil {
+			return nil, err
+		}
+		ys[i] = y
+	}
+	return concat(ys)
+}
+
+func (phi *ConcatImage) Size(x image.Point) image.Point {
+	sizes := make([]image.Point, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		sizes[i] = elem.Size(x)
+	}
+	return minSize(sizes)
+}
+
+func (phi *ConcatImage) MinInputSize(y image.Point) image.Point {
+	sizes := make([]image.Point, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		sizes[i] = elem.MinInputSize(y)
+	}
+	return maxSize(sizes)
+}
+
+func (phi *ConcatImage) Channels() int {
+	var n int
+	for _, elem := range phi.Elems {
+		n += elem.Channels()
+	}
+	return n
+}
+
+func (phi *ConcatImage) Marshaler() *ImageMarshaler {
+	// Obtain marshaler for each member.
+	ms := make([]Image, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		ms[i] = elem.Marshaler()
+	}
+	return &ImageMarshaler{"concat-image", &ConcatImage{ms}}
+}
+
+func (phi *ConcatImage) Transform() Image {
+	fs := make([]Image, len(phi.Elems))
+	for i, elem := range phi.Elems {
+		fs[i] = elem.Transform()
+	}
+	return &ConcatImage{fs}
+}
+
+// UnmarshalJSON decodes each member using an ImageMarshaler.
+func (phi *ConcatImage) UnmarshalJSON(data []byte) error {
+	var x struct{ Elems []*ImageMarshaler }
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	phi.Elems = make([]Image, len(x.Elems))
+	for i, m := range x.Elems {
+		if m == nil {
+			return fmt.Errorf("concat-image: element %d is null", i)
+		}
+		phi.Elems[i] = m
+	}
+	if len(phi.Elems) > 0 {
+		if _, err := imageRate(phi.Elems); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns an error if the transforms do not all have the same rate.
+func realRate(elems []Real) (int, error) {
+	if len(elems) == 0 {
+		return 0, errors.New("concat: no transforms")
+	}
+	rate := elems[0].Rate()
+	for _, elem := range elems[1:] {
+		if elem.Rate() != rate {
+			return 0, fmt.Errorf("concat: different rates: %d, %d", rate, elem.Rate())
+		}
+	}
+	return rate, nil
+}
+
+// Returns an error if the transforms do not all have the same rate.
+func imageRate(elems []Image) (int, error) {
+	if len(elems) == 0 {
+		return 0, errors.New("concat: no transforms")
+	}
+	rate := elems[0].Rate()
+	for _, elem := range elems[1:] {
+		if elem.Rate() != rate {
+			return 0, fmt.Errorf("concat: different rates: %d, %d", rate, elem.Rate())
+		}
+	}
+	return rate, nil
+}
+
+// Copies the channels of each image into one image.
+// Every image is cropped to the minimum size.
+// Returns nil if all images are nil.
+func concat(ys []*rimg64.Multi) (*rimg64.Multi, error) {
+	// If all nil, then return nil.
+	allNil := true
+	for _, y := range ys {
+		if y != nil {
+			allNil = false
+			break
+		}
+	}
+	if allNil {
+		return nil, nil
+	}
+	sizes := make([]image.Point, len(ys))
+	var channels int
+	for i, y := range ys {
+		if y == nil {
+			return nil, errors.New("concat: some transforms are nil")
+		}
+		sizes[i] = y.Size()
+		channels += y.Channels
+	}
+	size := minSize(sizes)
+	// Copy into one image.
+	z := rimg64.NewMulti(size.X, size.Y, channels)
+	var q int
+	for _, y := range ys {
+		for u := 0; u < size.X; u++ {
+			for v := 0; v < size.Y; v++ {
+				for p := 0; p < y.Channels; p++ {
+					z.Set(u, v, q+p, y.At(u, v, p))
+				}
+			}
+		}
+		q += y.Channels
+	}
+	return z, nil
+}
+
+func minSize(sizes []image.Point) image.Point {
+	var size image.Point
+	for i, s := range sizes {
+		if i == 0 {
+			size = s
+			continue
+		}
+		size.X = min(size.X, s.X)
+		size.Y = min(size.Y, s.Y)
+	}
+	return size
+}
+
+func maxSize(sizes []image.Point) image.Point {
+	var size image.Point
+	for i, s := range sizes {
+		if i == 0 {
+			size = s
+			continue
+		}
+		size.X = max(size.X, s.X)
+		size.Y = max(size.Y, s.Y)
+	}
+	return size
+}
@@ -0,0 +1,122 @@
+package featset_test
+
+import (
+	"encoding/json"
+	"image"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/featset"
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+func TestConcat_Apply(t *testing.T) {
+	x := rimg64.NewMulti(5, 4, 3)
+	for u := 0; u < x.Width; u++ {
+		for v := 0; v < x.Height; v++ {
+			for p := 0; p < x.Channels; p++ {
+				x.Set(u, v, p, float64(100*u+10*v+p))
+			}
+		}
+	}
+	phi := &featset.Concat{[]featset.Real{
+		&featset.SelectChannels{[]int{2}},
+		// Produces an image which is one pixel smaller.
+		&featset.Compose{Outer: validSum{}, Inner: &featset.ChannelInterval{0, 2}},
+	}}
+	if got := phi.Channels(); got != 3 {
+		t.Errorf("channels: want %d, got %d", 3, got)
+	}
+	size := image.Pt(4, 3)
+	if got := phi.Size(x.Size()); !got.Eq(size) {
+		t.Errorf("size: want %v, got %v", size, got)
+	}
+	if got := phi.MinInputSize(size); !got.Eq(x.Size()) {
+		t.Errorf("min input size: want %v, got %v", x.Size(), got)
+	}
+	y, err := phi.Apply(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !y.Size().Eq(size) || y.Channels != 3 {
+		t.Fatalf("want size %v with %d channels, got %v with %d", size, 3, y.Size(), y.Channels)
+	}
+	for u := 0; u < size.X; u++ {
+		for v := 0; v < size.Y; v++ {
+			want := []float64{
+				x.At(u, v, 2),
+				x.At(u, v, 0) + x.At(u+1, v+1, 0),
+				x.At(u, v, 1) + x.At(u+1, v+1, 1),
+			}
+			for p := range want {
+				if got := y.At(u, v, p); got != want[p] {
+					t.Errorf("at (%d, %d, %d): want %g, got %g", u, v, p, want[p], got)
+				}
+			}
+		}
+	}
+}
+
+func TestConcat_differentRates(t *testing.T) {
+	phi := &featset.Concat{[]featset.Real{
+		&featset.ChannelInterval{0, 1},
+		&featset.Compose{Outer: validSum{}, Inner: stride2{}},
+	}}
+	if _, err := phi.Apply(rimg64.NewMulti(8, 8, 1)); err == nil {
+		t.Error("expected error for different rates")
+	}
+}
+
+func TestConcat_UnmarshalJSON_differentRates(t *testing.T) {
+	featset.RegisterReal("stride2", func() featset.Real { return stride2{} })
+	data := []byte(`{"Elems": [{"Name": "channel-interval", "Spec": {"A": 0, "B": 1}}, {"Name": "stride2"}]}`)
+	var phi featset.Concat
+	if err := json.Unmarshal(data, &phi); err == nil {
+		t.Error("expected error for different rates")
+	}
+}
+
+// validSum adds each pixel to its diagonal neighbour.
+type validSum struct{}
+
+func (validSum) Rate() int                              { return 1 }
+func (validSum) Size(x image.Point) image.Point         { return x.Sub(image.Pt(1, 1)) }
+func (validSum) MinInputSize(y image.Point) image.Point { return y.Add(image.Pt(1, 1)) }
+func (validSum) Channels() int                          { return 2 }
+func (phi validSum) Marshaler() *featset.RealMarshaler {
+	return &featset.RealMarshaler{"valid-sum", phi}
+}
+func (phi validSum) Transform() featset.Real { return phi }
+
+func (validSum) Apply(x *rimg64.Multi) (*rimg64.Multi, error) {
+	y := rimg64.NewMulti(x.Width-1, x.Height-1, x.Channels)
+	for u := 0; u < y.Width; u++ {
+		for v := 0; v < y.Height; v++ {
+			for p := 0; p < y.Channels; p++ {
+				y.Set(u, v, p, x.At(u, v, p)+x.At(u+1, v+1, p))
+			}
+		}
+	}
+	return y, nil
+}
+
+// stride2 takes every second pixel.
+type stride2 struct{}
+
+func (stride2) Rate() int                              { return 2 }
+func (stride2) Size(x image.Point) image.Point         { return x.Div(2) }
+func (stride2) MinInputSize(y image.Point) image.Point { return y.Mul(2) }
+func (stride2) Channels() int                          { return 1 }
+func (phi stride2) Marshaler() *featset.RealMarshaler  { return &featset.RealMarshaler{"stride2", phi} }
+func (phi stride2) Transform() featset.Real            { return phi }
+
+func (stride2) Apply(x *rimg64.Multi) (*rimg64.Multi, error) {
+	y := rimg64.NewMulti(x.Width/2, x.Height/2, x.Channels)
+	for u := 0; u < y.Width; u++ {
+		for v := 0; v < y.Height; v++ {
+			for p := 0; p < y.Channels; p++ {
+				y.Set(u, v, p, x.At(2*u, 2*v, p))
+			}
+		}
+	}
+	return y, nil
+}
@@ -16,4 +16,36 @@ func TestImageMarshaler(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
+	err = featset.TestImageMarshaler(&featset.ConcatImage{[]featset.Image{
+		new(featset.Gray),
+		new(featset.RGB),
+	}})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRealMarshaler(t *testing.T) {
+	transforms := []featset.Real{
+		&featset.ChannelInterval{1, 3},
+		&featset.SelectChannels{[]int{2, 0}},
+		&featset.Concat{[]featset.Real{
+			&featset.ChannelInterval{1, 3},
+			&featset.SelectChannels{[]int{2, 0}},
+		}},
+		// Nested compound transforms.
+		&featset.Compose{
+			Outer: &featset.Concat{[]featset.Real{
+				&featset.ChannelInterval{0, 1},
+				&featset.ChannelInterval{0, 1},
+			}},
+			Inner: &featset.SelectChannels{[]int{1}},
+		},
+	}
+	for _, phi := range transforms {
+		err := featset.TestRealMarshaler(phi)
+		if err != nil {
+			t.Error(err)
+		}
+	}
 }
@@ -0,0 +1,15 @@
+package featset
+
+func min(a, b int) int {
+	if b < a {
+		return b
+	}
+	return a
+}
+
+func max(a, b int) int {
+	if a < b {
+		return b
+	}
+	return a
+}
@@ -1,6 +1,10 @@
 /*
 Histograms of Oriented Gradients features.
 
-Includes reference implementation of Felzenszwalb, Girshick, McAllester, Ramanan (FGMR).
+Includes a Go implementation of Felzenszwalb, Girshick, McAllester, Ramanan (FGMR).
+The original C implementation is only compiled with the build tag fgmrc,
+in which case the tests compare the two:
+
+	go test -tags fgmrc
 */
 package hog
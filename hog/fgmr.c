@@ -1,3 +1,6 @@
+//go:build cgo && fgmrc
+// +build cgo,fgmrc
+
 // AUTORIGHTS
 // -------------------------------------------------------
 // Copyright (C) 2011-2012 Ross Girshick
@@ -2,13 +2,9 @@ package hog
 
 // HOG implementation of Felzenszwalb, Girshick, McAllester, Ramanan (FGMR).
 
-// #cgo CFLAGS: -Wall -Werror
-// #cgo LDFLAGS: -lm
-// #include "fgmr.h"
-import "C"
-
 import (
-	"unsafe"
+	"image"
+	"math"
 
 	"github.com/jvlmdr/go-cv/rimg64"
 )
@@ -16,41 +12,168 @@ import (
 const Orientations = 9
 const Channels = 3*Orientations + 4
 
-func fgmr(im *rimg64.Multi, sbin int) *rimg64.Multi {
+// Unit vectors used to compute gradient orientation.
+// These are the truncated constants of the original implementation.
+var (
+	fgmrU = [Orientations]float64{1.0000, 0.9397, 0.7660, 0.500, 0.1736, -0.1736, -0.5000, -0.7660, -0.9397}
+	fgmrV = [Orientations]float64{0.0000, 0.3420, 0.6428, 0.8660, 0.9848, 0.9848, 0.8660, 0.6428, 0.3420}
+)
+
+// FGMR computes HOG features exactly as the voc-release code does.
+// The input image must have three channels.
+//
+// Unlike HOG, the number of cells is obtained by rounding
+// the size of the image divided by sbin, and the whole image is used.
+// The output has Channels channels:
+// 2*Orientations contrast-sensitive,
+// Orientations contrast-insensitive
+// and 4 texture features.
+func FGMR(im *rimg64.Multi, sbin int) *rimg64.Multi {
 	if im.Channels != 3 {
 		panic("Input image must have three channels")
 	}
 	if sbin < 1 {
 		panic("Bin size must be positive")
 	}
+	const eps = 0.0001
+
+	cells := fgmrCells(im.Width, im.Height, sbin)
+	out := fgmrSize(im.Width, im.Height, sbin)
+	hog := rimg64.NewMulti(out.X, out.Y, Channels)
+	if cells.X <= 0 || cells.Y <= 0 {
+		return hog
+	}
+	visible := cells.Mul(sbin)
+
+	// Accumulate edges into cell histograms.
+	hist := rimg64.NewMulti(cells.X, cells.Y, 2*Orientations)
+	for x := 1; x < visible.X-1; x++ {
+		for y := 1; y < visible.Y-1; y++ {
+			a := min(x, im.Width-2)
+			b := min(y, im.Height-2)
+
+			// Pick channel with strongest gradient.
+			var dx, dy, v float64
+			for d := 0; d < 3; d++ {
+				dxd := im.At(a+1, b, d) - im.At(a-1, b, d)
+				dyd := im.At(a, b+1, d) - im.At(a, b-1, d)
+				vd := dxd*dxd + dyd*dyd
+				if d == 0 || vd > v {
+					dx, dy, v = dxd, dyd, vd
+				}
+			}
+
+			// Snap to one of 18 orientations.
+			var (
+				bestDot float64
+				bestO   int
+			)
+			for o := 0; o < Orientations; o++ {
+				dot := fgmrU[o]*dx + fgmrV[o]*dy
+				if dot > bestDot {
+					bestDot, bestO = dot, o
+				} else if -dot > bestDot {
+					bestDot, bestO = -dot, o+Orientations
+				}
+			}
+
+			// Add to 4 histograms around pixel using bilinear interpolation.
+			xp := (float64(x)+0.5)/float64(sbin) - 0.5
+			yp := (float64(y)+0.5)/float64(sbin) - 0.5
+			ixp := int(math.Floor(xp))
+			iyp := int(math.Floor(yp))
+			vx0 := xp - float64(ixp)
+			vy0 := yp - float64(iyp)
+			vx1 := 1 - vx0
+			vy1 := 1 - vy0
+			v = math.Sqrt(v)
+
+			if ixp >= 0 && iyp >= 0 {
+				addToMulti(hist, ixp, iyp, bestO, vx1*vy1*v)
+			}
+			if ixp+1 < cells.X && iyp >= 0 {
+				addToMulti(hist, ixp+1, iyp, bestO, vx0*vy1*v)
+			}
+			if ixp >= 0 && iyp+1 < cells.Y {
+				addToMulti(hist, ixp, iyp+1, bestO, vx1*vy0*v)
+			}
+			if ixp+1 < cells.X && iyp+1 < cells.Y {
+				addToMulti(hist, ixp+1, iyp+1, bestO, vx0*vy0*v)
+			}
+		}
+	}
 
-	// Query size of workspace and output.
-	var (
-		dims  = [3]C.int{C.int(im.Height), C.int(im.Width), 3}
-		cells [2]C.int
-		out   [3]C.int
-	)
-	C.size(&dims[0], C.int(sbin), &cells[0], &out[0])
-
-	var (
-		// Allocate output.
-		hog = rimg64.NewMulti(int(out[1]), int(out[0]), int(out[2]))
-		// Allocate workspace.
-		numCells = cells[0] * cells[1]
-		hist     = make([]C.double, 18*numCells)
-		norm     = make([]C.double, numCells)
-	)
-
-	// Compute HOG features.
-	C.compute(
-		&dims[0],
-		(*C.double)(unsafe.Pointer(&im.Elems[0])),
-		(*C.double)(unsafe.Pointer(&hist[0])),
-		(*C.double)(unsafe.Pointer(&norm[0])),
-		C.int(sbin),
-		&cells[0],
-		&out[0],
-		(*C.double)(unsafe.Pointer(&hog.Elems[0])))
+	// Compute energy in each block by summing over orientations.
+	norm := rimg64.New(cells.X, cells.Y)
+	for o := 0; o < Orientations; o++ {
+		for x := 0; x < cells.X; x++ {
+			for y := 0; y < cells.Y; y++ {
+				s := hist.At(x, y, o) + hist.At(x, y, o+Orientations)
+				addTo(norm, x, y, s*s)
+			}
+		}
+	}
+
+	// Sum of the 2x2 block of cells whose top-left cell is (x, y).
+	block := func(x, y int) float64 {
+		return norm.At(x, y) + norm.At(x, y+1) + norm.At(x+1, y) + norm.At(x+1, y+1)
+	}
+
+	// Compute features.
+	for x := 0; x < out.X; x++ {
+		for y := 0; y < out.Y; y++ {
+			n1 := 1 / math.Sqrt(block(x+1, y+1)+eps)
+			n2 := 1 / math.Sqrt(block(x+1, y)+eps)
+			n3 := 1 / math.Sqrt(block(x, y+1)+eps)
+			n4 := 1 / math.Sqrt(block(x, y)+eps)
+
+			var t1, t2, t3, t4 float64
+			var off int
+			// Contrast-sensitive features.
+			for o := 0; o < 2*Orientations; o++ {
+				h := hist.At(x+1, y+1, o)
+				h1 := math.Min(h*n1, 0.2)
+				h2 := math.Min(h*n2, 0.2)
+				h3 := math.Min(h*n3, 0.2)
+				h4 := math.Min(h*n4, 0.2)
+				hog.Set(x, y, off+o, 0.5*(h1+h2+h3+h4))
+				t1 += h1
+				t2 += h2
+				t3 += h3
+				t4 += h4
+			}
+			off += 2 * Orientations
+
+			// Contrast-insensitive features.
+			for o := 0; o < Orientations; o++ {
+				h := hist.At(x+1, y+1, o) + hist.At(x+1, y+1, o+Orientations)
+				h1 := math.Min(h*n1, 0.2)
+				h2 := math.Min(h*n2, 0.2)
+				h3 := math.Min(h*n3, 0.2)
+				h4 := math.Min(h*n4, 0.2)
+				hog.Set(x, y, off+o, 0.5*(h1+h2+h3+h4))
+			}
+			off += Orientations
 
+			// Texture features.
+			hog.Set(x, y, off+0, 0.2357*t1)
+			hog.Set(x, y, off+1, 0.2357*t2)
+			hog.Set(x, y, off+2, 0.2357*t3)
+			hog.Set(x, y, off+3, 0.2357*t4)
+		}
+	}
 	return hog
 }
+
+// Number of cells for which histograms are computed.
+func fgmrCells(width, height, sbin int) image.Point {
+	x := round(float64(width) / float64(sbin))
+	y := round(float64(height) / float64(sbin))
+	return image.Pt(x, y)
+}
+
+// Size of the feature image.
+func fgmrSize(width, height, sbin int) image.Point {
+	cells := fgmrCells(width, height, sbin)
+	return image.Pt(max(cells.X-2, 0), max(cells.Y-2, 0))
+}
@@ -0,0 +1,69 @@
+//go:build cgo && fgmrc
+// +build cgo,fgmrc
+
+package hog
+
+import (
+	"image"
+	_ "image/jpeg"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+func TestFGMR_VersusC(t *testing.T) {
+	const prec = 1e-12
+	for _, fname := range []string{"000034.jpg", "000061.jpg", "000084.jpg"} {
+		file, err := os.Open(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		im, _, err := image.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := rimg64.FromColor(im)
+		for _, sbin := range []int{4, 7, 8} {
+			want := fgmrC(f, sbin)
+			got := FGMR(f, sbin)
+			if !want.Size().Eq(got.Size()) || want.Channels != got.Channels {
+				t.Errorf("%s, sbin %d: want size %v with %d channels, got %v with %d",
+					fname, sbin, want.Size(), want.Channels, got.Size(), got.Channels)
+				continue
+			}
+			for i := range want.Elems {
+				if math.Abs(want.Elems[i]-got.Elems[i]) > prec {
+					t.Errorf("%s, sbin %d: wrong value at index %d: want %g, got %g",
+						fname, sbin, i, want.Elems[i], got.Elems[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkC(b *testing.B) {
+	const (
+		sbin  = 8
+		fname = "000084.jpg"
+	)
+
+	// Load image.
+	file, err := os.Open(fname)
+	if err != nil {
+		b.Fatal(err)
+	}
+	im, _, err := image.Decode(file)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	f := rimg64.FromColor(im)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fgmrC(f, sbin)
+	}
+}
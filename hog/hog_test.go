@@ -33,7 +33,7 @@ func TestHOG_VersusFGMR(t *testing.T) {
 	inside := image.NewRGBA(image.Rectangle{image.ZP, rect.Size()})
 	draw.Draw(inside, inside.Bounds(), im, rect.Min, draw.Src)
 	// Compute transforms.
-	ref := fgmr(rimg64.FromColor(inside), sbin)
+	ref := FGMR(rimg64.FromColor(inside), sbin)
 	f := HOG(rimg64.FromColor(im), FGMRConfig(sbin))
 
 	const prec = 1e-5
@@ -136,7 +136,7 @@ func BenchmarkGo(b *testing.B) {
 	}
 }
 
-func BenchmarkC(b *testing.B) {
+func BenchmarkFGMR(b *testing.B) {
 	const (
 		sbin  = 8
 		fname = "000084.jpg"
@@ -155,7 +155,7 @@ func BenchmarkC(b *testing.B) {
 	f := rimg64.FromColor(im)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		fgmr(f, sbin)
+		FGMR(f, sbin)
 	}
 }
 
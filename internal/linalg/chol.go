@@ -0,0 +1,54 @@
+// Package linalg provides the dense linear algebra
+// which is shared by the training packages.
+package linalg
+
+import (
+	"fmt"
+	"math"
+)
+
+// Cholesky replaces the lower triangle of a symmetric positive definite matrix
+// with its Cholesky factor L such that A = L L'.
+func Cholesky(a [][]float64) error {
+	n := len(a)
+	for j := 0; j < n; j++ {
+		d := a[j][j]
+		for k := 0; k < j; k++ {
+			d -= a[j][k] * a[j][k]
+		}
+		if !(d > 0) {
+			return fmt.Errorf("matrix is not positive definite (pivot %d is %g)", j, d)
+		}
+		d = math.Sqrt(d)
+		a[j][j] = d
+		for i := j + 1; i < n; i++ {
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= a[i][k] * a[j][k]
+			}
+			a[i][j] = s / d
+		}
+	}
+	return nil
+}
+
+// CholSolve solves L L' x = b in-place given the Cholesky factor L.
+func CholSolve(l [][]float64, b []float64) {
+	n := len(b)
+	// Forward substitution.
+	for i := 0; i < n; i++ {
+		s := b[i]
+		for k := 0; k < i; k++ {
+			s -= l[i][k] * b[k]
+		}
+		b[i] = s / l[i][i]
+	}
+	// Backward substitution.
+	for i := n - 1; i >= 0; i-- {
+		s := b[i]
+		for k := i + 1; k < n; k++ {
+			s -= l[k][i] * b[k]
+		}
+		b[i] = s / l[i][i]
+	}
+}
@@ -0,0 +1,14 @@
+/*
+Package lda constructs templates by linear discriminant analysis.
+
+The mean and spatial autocovariance of a feature transform
+are estimated once from a set of background images.
+Since the statistics are assumed to be stationary,
+the covariance of a window of any size can be constructed from them,
+and a template is obtained from the positive examples alone
+without mining negative examples.
+
+See Hariharan, Malik and Ramanan,
+"Discriminative decorrelation for clustering and classification" (2012).
+*/
+package lda
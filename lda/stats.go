@@ -0,0 +1,183 @@
+package lda
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/jvlmdr/go-cv/feat"
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+// Stats describes the mean and spatial autocovariance
+// of a stationary multi-channel image.
+type Stats struct {
+	Channels int
+	// Maximum offset in either direction for which the covariance is known.
+	Band int
+	// Mean of each channel.
+	Mean []float64
+	// Covariance of channel p at position x with channel q at position x+d,
+	// for d in [-Band, Band] x [-Band, Band].
+	// Element (d, p, q) at index (i*(2*Band+1) + j)*Channels*Channels + p*Channels + q,
+	// where (i, j) = d + (Band, Band).
+	Cov []float64
+}
+
+// At returns the covariance of channel p at x with channel q at x+d.
+func (s *Stats) At(d image.Point, p, q int) float64 {
+	return s.Cov[covIndex(s.Band, s.Channels, d, p, q)]
+}
+
+func covIndex(band, channels int, d image.Point, p, q int) int {
+	i, j := d.X+band, d.Y+band
+	return (i*(2*band+1)+j)*channels*channels + p*channels + q
+}
+
+// Accum accumulates the statistics of a set of images.
+type Accum struct {
+	Channels int
+	Band     int
+	// Sum of each channel and number of pixels.
+	sum []float64
+	num int
+	// Sum of products for each offset and number of pairs.
+	prod  []float64
+	pairs []int
+}
+
+// NewAccum creates an empty accumulator.
+func NewAccum(channels, band int) *Accum {
+	n := 2*band + 1
+	return &Accum{
+		Channels: channels,
+		Band:     band,
+		sum:      make([]float64, channels),
+		prod:     make([]float64, n*n*channels*channels),
+		pairs:    make([]int, n*n),
+	}
+}
+
+// Add adds the pixels of one image to the statistics.
+func (a *Accum) Add(f *rimg64.Multi) error {
+	if f.Channels != a.Channels {
+		return fmt.Errorf("different channels: accumulator %d, image %d", a.Channels, f.Channels)
+	}
+	c := a.Channels
+	for x := 0; x < f.Width; x++ {
+		for y := 0; y < f.Height; y++ {
+			for p := 0; p < c; p++ {
+				a.sum[p] += f.At(x, y, p)
+			}
+		}
+	}
+	a.num += f.Width * f.Height
+
+	n := 2*a.Band + 1
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			d := image.Pt(i-a.Band, j-a.Band)
+			// Range of x such that x and x+d are both in the image.
+			r := image.Rect(0, 0, f.Width, f.Height).Intersect(image.Rect(-d.X, -d.Y, f.Width-d.X, f.Height-d.Y))
+			if r.Empty() {
+				continue
+			}
+			a.pairs[i*n+j] += r.Dx() * r.Dy()
+			off := (i*n + j) * c * c
+			for x := r.Min.X; x < r.Max.X; x++ {
+				for y := r.Min.Y; y < r.Max.Y; y++ {
+					for p := 0; p < c; p++ {
+						fp := f.At(x, y, p)
+						for q := 0; q < c; q++ {
+							a.prod[off+p*c+q] += fp * f.At(x+d.X, y+d.Y, q)
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Stats computes the mean and covariance.
+// Returns an error if some offset was never observed.
+func (a *Accum) Stats() (*Stats, error) {
+	if a.num == 0 {
+		return nil, errors.New("no pixels")
+	}
+	c := a.Channels
+	mean := make([]float64, c)
+	for p := range mean {
+		mean[p] = a.sum[p] / float64(a.num)
+	}
+	n := 2*a.Band + 1
+	cov := make([]float64, len(a.prod))
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			k := a.pairs[i*n+j]
+			if k == 0 {
+				return nil, fmt.Errorf("no pairs at offset %v", image.Pt(i-a.Band, j-a.Band))
+			}
+			off := (i*n + j) * c * c
+			for p := 0; p < c; p++ {
+				for q := 0; q < c; q++ {
+					cov[off+p*c+q] = a.prod[off+p*c+q]/float64(k) - mean[p]*mean[q]
+				}
+			}
+		}
+	}
+	return &Stats{Channels: c, Band: a.Band, Mean: mean, Cov: cov}, nil
+}
+
+// Estimate computes the statistics of a feature transform
+// over a set of background images.
+// The band is the maximum offset for which covariance is computed,
+// which limits the size of template that can be constructed.
+func Estimate(ims []image.Image, phi feat.Image, band int) (*Stats, error) {
+	a := NewAccum(phi.Channels(), band)
+	for _, im := range ims {
+		f, err := phi.Apply(im)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			continue
+		}
+		if err := a.Add(f); err != nil {
+			return nil, err
+		}
+	}
+	return a.Stats()
+}
+
+// Covar constructs the covariance of a window of the given size.
+// The elements are ordered as in rimg64.Multi.
+// Returns an error if the window exceeds the band.
+func (s *Stats) Covar(size image.Point) ([][]float64, error) {
+	if size.X-1 > s.Band || size.Y-1 > s.Band {
+		return nil, fmt.Errorf("window %v exceeds band %d", size, s.Band)
+	}
+	c := s.Channels
+	n := size.X * size.Y * c
+	cov := make([][]float64, n)
+	for i := range cov {
+		cov[i] = make([]float64, n)
+	}
+	for x1 := 0; x1 < size.X; x1++ {
+		for y1 := 0; y1 < size.Y; y1++ {
+			for x2 := 0; x2 < size.X; x2++ {
+				for y2 := 0; y2 < size.Y; y2++ {
+					d := image.Pt(x2-x1, y2-y1)
+					for p := 0; p < c; p++ {
+						for q := 0; q < c; q++ {
+							i := (x1*size.Y+y1)*c + p
+							j := (x2*size.Y+y2)*c + q
+							cov[i][j] = s.At(d, p, q)
+						}
+					}
+				}
+			}
+		}
+	}
+	return cov, nil
+}
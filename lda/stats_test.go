@@ -0,0 +1,105 @@
+package lda_test
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/lda"
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+// Generates a moving-average process
+// 	f(x, y, p) = g(x, y, p) + g(x+1, y, p) + mean[p]
+// where g is white noise with unit variance.
+func movingAverage(width, height int, mean []float64, r *rand.Rand) *rimg64.Multi {
+	g := rimg64.NewMulti(width+1, height, len(mean))
+	for i := range g.Elems {
+		g.Elems[i] = r.NormFloat64()
+	}
+	f := rimg64.NewMulti(width, height, len(mean))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			for p := range mean {
+				f.Set(x, y, p, g.At(x, y, p)+g.At(x+1, y, p)+mean[p])
+			}
+		}
+	}
+	return f
+}
+
+func TestAccum_movingAverage(t *testing.T) {
+	const (
+		band = 2
+		eps  = 0.05
+	)
+	r := rand.New(rand.NewSource(1))
+	mean := []float64{1, -2}
+	a := lda.NewAccum(len(mean), band)
+	for i := 0; i < 4; i++ {
+		if err := a.Add(movingAverage(200, 200, mean, r)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := a.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p := range mean {
+		if math.Abs(s.Mean[p]-mean[p]) > eps {
+			t.Errorf("mean of channel %d: want %g, got %g", p, mean[p], s.Mean[p])
+		}
+	}
+	for dx := -band; dx <= band; dx++ {
+		for dy := -band; dy <= band; dy++ {
+			d := image.Pt(dx, dy)
+			for p := range mean {
+				for q := range mean {
+					var want float64
+					if p == q && dy == 0 {
+						switch dx {
+						case 0:
+							want = 2
+						case -1, 1:
+							want = 1
+						}
+					}
+					if got := s.At(d, p, q); math.Abs(got-want) > eps {
+						t.Errorf("cov at %v, (%d, %d): want %g, got %g", d, p, q, want, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestStats_Covar(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	a := lda.NewAccum(3, 3)
+	if err := a.Add(movingAverage(20, 15, []float64{0, 0, 0}, r)); err != nil {
+		t.Fatal(err)
+	}
+	s, err := a.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := image.Pt(4, 3)
+	cov, err := s.Covar(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := size.X * size.Y * 3; len(cov) != n {
+		t.Fatalf("wrong dimension: want %d, got %d", n, len(cov))
+	}
+	for i := range cov {
+		for j := range cov {
+			if cov[i][j] != cov[j][i] {
+				t.Fatalf("not symmetric at (%d, %d): %g, %g", i, j, cov[i][j], cov[j][i])
+			}
+		}
+	}
+	if _, err := s.Covar(image.Pt(5, 3)); err == nil {
+		t.Error("expected error for window larger than band")
+	}
+}
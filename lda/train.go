@@ -0,0 +1,67 @@
+package lda
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jvlmdr/go-cv/internal/linalg"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/slide"
+)
+
+// Train constructs a linear discriminant between
+// the mean of the positive windows and the background.
+// 	w = (S + lambda I)^-1 (mu_pos - mu_bg)
+// 	b = -w' (mu_pos + mu_bg) / 2
+// where S is the background covariance of a window.
+// The regularization lambda must be non-negative.
+func Train(s *Stats, pos []*rimg64.Multi, lambda float64) (*slide.AffineScorer, error) {
+	if len(pos) == 0 {
+		return nil, errors.New("no positive examples")
+	}
+	if lambda < 0 {
+		return nil, fmt.Errorf("regularization is negative: %g", lambda)
+	}
+	ref := pos[0]
+	if ref.Channels != s.Channels {
+		return nil, fmt.Errorf("different channels: statistics %d, examples %d", s.Channels, ref.Channels)
+	}
+	// Mean of positive examples.
+	mu := make([]float64, len(ref.Elems))
+	for i, x := range pos {
+		if !x.Size().Eq(ref.Size()) || x.Channels != ref.Channels {
+			return nil, fmt.Errorf("example %d: different size: %v x %d, %v x %d", i, x.Size(), x.Channels, ref.Size(), ref.Channels)
+		}
+		for j, v := range x.Elems {
+			mu[j] += v / float64(len(pos))
+		}
+	}
+	// Mean of background window.
+	bg := make([]float64, len(mu))
+	for j := range bg {
+		bg[j] = s.Mean[j%s.Channels]
+	}
+
+	a, err := s.Covar(ref.Size())
+	if err != nil {
+		return nil, err
+	}
+	for i := range a {
+		a[i][i] += lambda
+	}
+	if err := linalg.Cholesky(a); err != nil {
+		return nil, err
+	}
+	w := make([]float64, len(mu))
+	for j := range w {
+		w[j] = mu[j] - bg[j]
+	}
+	linalg.CholSolve(a, w)
+
+	var b float64
+	for j := range w {
+		b -= w[j] * (mu[j] + bg[j]) / 2
+	}
+	tmpl := &rimg64.Multi{w, ref.Width, ref.Height, ref.Channels}
+	return &slide.AffineScorer{Tmpl: tmpl, Bias: b}, nil
+}
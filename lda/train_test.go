@@ -0,0 +1,69 @@
+package lda_test
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/lda"
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+func TestTrain(t *testing.T) {
+	const (
+		lambda = 0.1
+		eps    = 1e-9
+	)
+	r := rand.New(rand.NewSource(1))
+	mean := []float64{0.5, -1}
+	a := lda.NewAccum(len(mean), 3)
+	if err := a.Add(movingAverage(50, 50, mean, r)); err != nil {
+		t.Fatal(err)
+	}
+	s, err := a.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := image.Pt(3, 4)
+	var pos []*rimg64.Multi
+	for i := 0; i < 10; i++ {
+		x := movingAverage(size.X, size.Y, mean, r)
+		x.Set(1, 1, 0, x.At(1, 1, 0)+3)
+		pos = append(pos, x)
+	}
+	scorer, err := lda.Train(s, pos, lambda)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check that (S + lambda I) w = mu_pos - mu_bg.
+	cov, err := s.Covar(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := scorer.Tmpl.Elems
+	mu := make([]float64, len(w))
+	for _, x := range pos {
+		for j, v := range x.Elems {
+			mu[j] += v / float64(len(pos))
+		}
+	}
+	var b float64
+	for i := range cov {
+		var lhs float64
+		for j := range cov {
+			lhs += cov[i][j] * w[j]
+		}
+		lhs += lambda * w[i]
+		bg := s.Mean[i%len(mean)]
+		if rhs := mu[i] - bg; math.Abs(lhs-rhs) > eps {
+			t.Errorf("row %d: want %g, got %g", i, rhs, lhs)
+		}
+		b -= w[i] * (mu[i] + bg) / 2
+	}
+	if math.Abs(b-scorer.Bias) > eps {
+		t.Errorf("bias: want %g, got %g", b, scorer.Bias)
+	}
+}
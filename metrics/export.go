@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// WriteJSON writes the events as a JSON array.
+// Durations are in nanoseconds.
+func WriteJSON(w io.Writer, events []Event) error {
+	if events == nil {
+		events = []Event{}
+	}
+	return json.NewEncoder(w).Encode(events)
+}
+
+// ReadJSON reads events which were written by WriteJSON.
+func ReadJSON(r io.Reader) ([]Event, error) {
+	var events []Event
+	if err := json.NewDecoder(r).Decode(&events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CSVHeader gives the columns written by WriteCSV.
+var CSVHeader = []string{"image", "stage", "level", "seconds", "width", "height", "count"}
+
+// WriteCSV writes the events as comma-separated values with a header.
+// Durations are in seconds.
+func WriteCSV(w io.Writer, events []Event) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, e := range events {
+		rec := []string{
+			e.Image,
+			e.Stage,
+			strconv.Itoa(e.Level),
+			strconv.FormatFloat(e.Dur.Seconds(), 'g', -1, 64),
+			strconv.Itoa(e.Size.X),
+			strconv.Itoa(e.Size.Y),
+			strconv.Itoa(e.Count),
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,63 @@
+package metrics_test
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jvlmdr/go-cv/metrics"
+)
+
+func testEvents() []metrics.Event {
+	var c metrics.Collector
+	rec := metrics.Tag(&c, "a.png")
+	rec.Record(metrics.Event{Stage: metrics.Resize, Level: 0, Dur: 2 * time.Millisecond, Size: image.Pt(64, 48)})
+	rec.Record(metrics.Event{Stage: metrics.Slide, Level: 0, Dur: 1500 * time.Microsecond, Size: image.Pt(10, 8)})
+	rec.Record(metrics.Event{Stage: metrics.Suppr, Level: -1, Dur: time.Microsecond, Count: 3})
+	return c.Events()
+}
+
+func TestWriteJSON(t *testing.T) {
+	want := testEvents()
+	var buf bytes.Buffer
+	if err := metrics.WriteJSON(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := metrics.ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := metrics.WriteCSV(&buf, testEvents()); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"image,stage,level,seconds,width,height,count",
+		"a.png,resize,0,0.002,64,48,0",
+		"a.png,slide,0,0.0015,10,8,0",
+		"a.png,suppr,-1,1e-06,0,0,3",
+	}, "\n") + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	events := append(testEvents(), metrics.Event{Stage: metrics.Slide, Level: 1, Dur: 500 * time.Microsecond})
+	totals := metrics.Totals(events)
+	if got := totals[metrics.Slide]; got.Dur != 2*time.Millisecond || got.Num != 2 {
+		t.Errorf("slide: want 2ms over 2 events, got %v over %d", got.Dur, got.Num)
+	}
+	if got := totals[metrics.Suppr].Count; got != 3 {
+		t.Errorf("suppr: want count 3, got %d", got)
+	}
+}
@@ -0,0 +1,131 @@
+// Package metrics records measurements from the stages of detection.
+//
+// Each stage reports an Event to a Recorder.
+// Events can be accumulated in memory using a Collector
+// and exported as JSON or CSV.
+package metrics
+
+import (
+	"image"
+	"sync"
+	"time"
+)
+
+// Names of the stages which are reported by this module.
+const (
+	// Loading an image from a file.
+	Load = "load"
+	// Rescaling the image at one level of a pyramid.
+	// Size is the size of the rescaled image.
+	Resize = "resize"
+	// Computing the features at one level of a pyramid.
+	// Size is the size of the feature image.
+	Feat = "feat"
+	// Evaluating a scorer at one level of a pyramid.
+	// Size is the size of the response.
+	Slide = "slide"
+	// Extracting candidate detections from the responses.
+	// Count is the number of candidates at the level.
+	// The time is given by a single event at level -1.
+	Points = "points"
+	// Non-max suppression.
+	// Count is the number of detections which remain.
+	Suppr = "suppr"
+)
+
+// Event is a measurement of one stage.
+type Event struct {
+	// Identifies the image, if known.
+	Image string `json:",omitempty"`
+	Stage string
+	// Level of the pyramid, or -1 if the event is not specific to a level.
+	Level int
+	// Time taken by the stage.
+	Dur time.Duration
+	// Size of the output of the stage, if applicable.
+	Size image.Point
+	// Number of items produced by the stage, if applicable.
+	Count int
+}
+
+// Recorder receives events.
+// It must be safe for concurrent use.
+type Recorder interface {
+	Record(e Event)
+}
+
+// Record passes the event to the recorder if it is not nil.
+func Record(r Recorder, e Event) {
+	if r != nil {
+		r.Record(e)
+	}
+}
+
+// Tag returns a recorder which sets the image of every event
+// before passing it to r.
+// Returns nil if r is nil.
+func Tag(r Recorder, image string) Recorder {
+	if r == nil {
+		return nil
+	}
+	return &tagged{r, image}
+}
+
+type tagged struct {
+	r     Recorder
+	image string
+}
+
+func (t *tagged) Record(e Event) {
+	e.Image = t.image
+	t.r.Record(e)
+}
+
+// Collector keeps every event in memory.
+// The zero value is an empty collector.
+type Collector struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (c *Collector) Record(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+}
+
+// Events returns a copy of the events in the order they were received.
+func (c *Collector) Events() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Event(nil), c.events...)
+}
+
+// Reset discards all events.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = nil
+}
+
+// Total gives the cumulative time and count of one stage.
+type Total struct {
+	Dur   time.Duration
+	Count int
+	// Number of events.
+	Num int
+}
+
+// Totals sums the time and count of each stage over all events.
+// Time which is summed over concurrent events may exceed the elapsed time.
+func Totals(events []Event) map[string]Total {
+	totals := make(map[string]Total)
+	for _, e := range events {
+		t := totals[e.Stage]
+		t.Dur += e.Dur
+		t.Count += e.Count
+		t.Num++
+		totals[e.Stage] = t
+	}
+	return totals
+}
@@ -25,7 +25,7 @@ func (f *AffineScorer) Size() image.Point {
 }
 
 func (f *AffineScorer) Score(x *rimg64.Multi) (float64, error) {
-	if f.Op != Cos {
+	if f.Op != Dot {
 		panic("cosine unimplemented")
 	}
 	if !x.Size().Eq(f.Tmpl.Size()) {
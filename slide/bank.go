@@ -4,7 +4,6 @@ import (
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 	"github.com/jvlmdr/lin-go/blas"
 )
 
@@ -55,14 +54,14 @@ func CorrBankFFT(f *rimg64.Image, g *Bank) (*rimg64.Multi, error) {
 	// Determine optimal size for FFT.
 	work, _ := FFT2Size(f.Size())
 	// Re-use FFT of image.
-	fhat := fftw.NewArray2(work.X, work.Y)
+	fhat := newArray2(work.X, work.Y)
 	copyImageTo(fhat, f)
-	fftw.FFT2To(fhat, fhat)
+	fft2(fhat)
 	// Transform of each filter.
-	curr := fftw.NewArray2(work.X, work.Y)
-	fwd := fftw.NewPlan2(curr, curr, fftw.Forward, fftw.Estimate)
+	curr := newArray2(work.X, work.Y)
+	fwd := newPlan2(curr, forward)
 	defer fwd.Destroy()
-	bwd := fftw.NewPlan2(curr, curr, fftw.Backward, fftw.Estimate)
+	bwd := newPlan2(curr, backward)
 	defer bwd.Destroy()
 
 	h := rimg64.NewMulti(out.X, out.Y, len(g.Filters))
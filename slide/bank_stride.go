@@ -4,7 +4,6 @@ import (
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 	"github.com/jvlmdr/lin-go/blas"
 )
 
@@ -59,17 +58,17 @@ func CorrBankStrideFFT(f *rimg64.Image, g *Bank, stride int) (*rimg64.Multi, err
 	work, _ := FFT2Size(fsub)
 	// Cache FFT of image for convolving with multiple filters.
 	// Re-use plan for multiple convolutions too.
-	fhat := fftw.NewArray2(work.X, work.Y)
-	ffwd := fftw.NewPlan2(fhat, fhat, fftw.Forward, fftw.Estimate)
+	fhat := newArray2(work.X, work.Y)
+	ffwd := newPlan2(fhat, forward)
 	defer ffwd.Destroy()
 	// FFT for current filter.
-	ghat := fftw.NewArray2(work.X, work.Y)
-	gfwd := fftw.NewPlan2(ghat, ghat, fftw.Forward, fftw.Estimate)
+	ghat := newArray2(work.X, work.Y)
+	gfwd := newPlan2(ghat, forward)
 	defer gfwd.Destroy()
 	// Allocate one array per output channel.
-	hhat := make([]*fftw.Array2, len(g.Filters))
+	hhat := make([]*array2, len(g.Filters))
 	for k := range hhat {
-		hhat[k] = fftw.NewArray2(work.X, work.Y)
+		hhat[k] = newArray2(work.X, work.Y)
 	}
 	// Normalization factor.
 	alpha := complex(1/float64(work.X*work.Y), 0)
@@ -91,7 +90,7 @@ func CorrBankStrideFFT(f *rimg64.Image, g *Bank, stride int) (*rimg64.Multi, err
 	h := rimg64.NewMulti(out.X, out.Y, len(g.Filters))
 	for q := range hhat {
 		scale(alpha, hhat[q])
-		fftw.IFFT2To(hhat[q], hhat[q])
+		ifft2(hhat[q])
 		copyRealToChannel(h, q, hhat[q])
 	}
 	return h, nil
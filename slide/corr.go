@@ -4,7 +4,6 @@ import (
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 	"github.com/jvlmdr/lin-go/blas"
 )
 
@@ -70,19 +69,19 @@ func CorrFFT(f, g *rimg64.Image) (*rimg64.Image, error) {
 	}
 	// Determine optimal size for FFT.
 	work, _ := FFT2Size(f.Size())
-	fhat := fftw.NewArray2(work.X, work.Y)
-	ghat := fftw.NewArray2(work.X, work.Y)
+	fhat := newArray2(work.X, work.Y)
+	ghat := newArray2(work.X, work.Y)
 	// Take forward transforms.
 	copyImageTo(fhat, f)
-	fftw.FFT2To(fhat, fhat)
+	fft2(fhat)
 	copyImageTo(ghat, g)
-	fftw.FFT2To(ghat, ghat)
+	fft2(ghat)
 	// Scale such that convolution theorem holds.
 	n := float64(work.X * work.Y)
 	scaleMul(fhat, complex(1/n, 0), ghat, fhat)
 	// Take inverse transform.
 	h := rimg64.New(out.X, out.Y)
-	fftw.IFFT2To(fhat, fhat)
+	ifft2(fhat)
 	copyRealTo(h, fhat)
 	return h, nil
 }
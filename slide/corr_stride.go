@@ -4,7 +4,6 @@ import (
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 	"github.com/jvlmdr/lin-go/blas"
 )
 
@@ -53,17 +52,17 @@ func CorrStrideFFT(f, g *rimg64.Image, stride int) (*rimg64.Image, error) {
 	work, _ := FFT2Size(fsub)
 	// Cache FFT of each channel of image for convolving with multiple filters.
 	// Re-use plan for multiple convolutions too.
-	fhat := fftw.NewArray2(work.X, work.Y)
-	ffwd := fftw.NewPlan2(fhat, fhat, fftw.Forward, fftw.Estimate)
+	fhat := newArray2(work.X, work.Y)
+	ffwd := newPlan2(fhat, forward)
 	defer ffwd.Destroy()
 	// FFT for current filter.
-	curr := fftw.NewArray2(work.X, work.Y)
-	gfwd := fftw.NewPlan2(curr, curr, fftw.Forward, fftw.Estimate)
+	curr := newArray2(work.X, work.Y)
+	gfwd := newPlan2(curr, forward)
 	defer gfwd.Destroy()
 	// Normalization factor.
 	alpha := complex(1/float64(work.X*work.Y), 0)
 	// Add the convolutions over strides.
-	hhat := fftw.NewArray2(work.X, work.Y)
+	hhat := newArray2(work.X, work.Y)
 	for i := 0; i < grid.X; i++ {
 		for j := 0; j < grid.Y; j++ {
 			// Copy each downsampled channel and take its transform.
@@ -77,7 +76,7 @@ func CorrStrideFFT(f, g *rimg64.Image, stride int) (*rimg64.Image, error) {
 	// Take the inverse transform.
 	h := rimg64.New(out.X, out.Y)
 	scale(alpha, hhat)
-	fftw.IFFT2To(hhat, hhat)
+	ifft2(hhat)
 	copyRealTo(h, hhat)
 	return h, nil
 }
@@ -6,5 +6,10 @@ Function names are matched by this regular expression:
 "Multi" means that the input image has multiple channels.
 "Bank" means that there is a bank of filters and therefore the output image has multiple channels.
 Not all combinations are implemented.
+
+Fourier transforms are computed using FFTW by default.
+If cgo is not available or the build tag nofftw is given,
+then a Go implementation is used instead:
+	go test -tags nofftw
 */
 package slide
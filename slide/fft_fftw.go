@@ -0,0 +1,32 @@
+//go:build cgo && !nofftw
+// +build cgo,!nofftw
+
+package slide
+
+import "github.com/jvlmdr/go-fftw/fftw"
+
+// Fourier transforms are computed by FFTW.
+// Build with the tag nofftw (or without cgo) to use the Go implementation.
+
+type (
+	array2 = fftw.Array2
+	plan   = fftw.Plan
+)
+
+func newArray2(n0, n1 int) *array2 {
+	return fftw.NewArray2(n0, n1)
+}
+
+// newPlan2 creates an in-place plan.
+func newPlan2(x *array2, dir direction) *plan {
+	if dir == backward {
+		return fftw.NewPlan2(x, x, fftw.Backward, fftw.Estimate)
+	}
+	return fftw.NewPlan2(x, x, fftw.Forward, fftw.Estimate)
+}
+
+// fft2 computes the forward transform in-place.
+func fft2(x *array2) { fftw.FFT2To(x, x) }
+
+// ifft2 computes the un-normalized inverse transform in-place.
+func ifft2(x *array2) { fftw.IFFT2To(x, x) }
@@ -0,0 +1,68 @@
+//go:build !cgo || nofftw
+// +build !cgo nofftw
+
+package slide
+
+// Fourier transforms are computed by the Go implementation in fftgo.go.
+// This is used when the tag nofftw is given or cgo is unavailable.
+
+// array2 is a two-dimensional complex array.
+// Element (i, j) is at index i*N[1] + j.
+type array2 struct {
+	Elems []complex128
+	N     [2]int
+}
+
+func newArray2(n0, n1 int) *array2 {
+	return &array2{make([]complex128, n0*n1), [2]int{n0, n1}}
+}
+
+func (x *array2) Dims() (n0, n1 int)         { return x.N[0], x.N[1] }
+func (x *array2) At(i, j int) complex128     { return x.Elems[i*x.N[1]+j] }
+func (x *array2) Set(i, j int, v complex128) { x.Elems[i*x.N[1]+j] = v }
+
+// plan describes an in-place two-dimensional transform.
+type plan struct {
+	x      *array2
+	p0, p1 *fftPlan
+	// Buffer for one row or column.
+	buf []complex128
+}
+
+// newPlan2 creates an in-place plan.
+func newPlan2(x *array2, dir direction) *plan {
+	n0, n1 := x.Dims()
+	return &plan{
+		x:   x,
+		p0:  newFFTPlan(n0, dir),
+		p1:  newFFTPlan(n1, dir),
+		buf: make([]complex128, max(n0, n1)),
+	}
+}
+
+// Execute computes the transform of the array which was planned.
+func (p *plan) Execute() {
+	n0, n1 := p.x.Dims()
+	// Transform along the second dimension (contiguous).
+	for i := 0; i < n0; i++ {
+		row := p.x.Elems[i*n1 : (i+1)*n1]
+		p.p1.Transform(p.buf[:n1], row, 1)
+		copy(row, p.buf[:n1])
+	}
+	// Transform along the first dimension (stride n1).
+	for j := 0; j < n1; j++ {
+		p.p0.Transform(p.buf[:n0], p.x.Elems[j:], n1)
+		for i := 0; i < n0; i++ {
+			p.x.Elems[i*n1+j] = p.buf[i]
+		}
+	}
+}
+
+// Destroy exists for compatibility with FFTW plans.
+func (p *plan) Destroy() {}
+
+// fft2 computes the forward transform in-place.
+func fft2(x *array2) { newPlan2(x, forward).Execute() }
+
+// ifft2 computes the un-normalized inverse transform in-place.
+func ifft2(x *array2) { newPlan2(x, backward).Execute() }
@@ -0,0 +1,107 @@
+package slide
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// direction is the sign of the exponent in a Fourier transform.
+type direction int
+
+const (
+	// exp(-2 pi i k t / n)
+	forward direction = iota
+	// exp(+2 pi i k t / n), not normalized
+	backward
+)
+
+// fftPlan computes one-dimensional discrete Fourier transforms
+// of a fixed length using a mixed-radix Cooley-Tukey algorithm.
+// This is the transform behind the Go backend.
+// It is fastest when the length is a product of the primes in FFTLen.
+// Larger prime factors are transformed naively.
+type fftPlan struct {
+	n int
+	// Twiddle factors w[k] = exp(-+2 pi i k / n).
+	w []complex128
+	// Workspace for butterflies.
+	tmp []complex128
+}
+
+func newFFTPlan(n int, dir direction) *fftPlan {
+	sign := -1.0
+	if dir == backward {
+		sign = 1
+	}
+	w := make([]complex128, n)
+	for k := range w {
+		theta := sign * 2 * math.Pi * float64(k) / float64(n)
+		w[k] = cmplx.Rect(1, theta)
+	}
+	return &fftPlan{n: n, w: w, tmp: make([]complex128, n)}
+}
+
+// Transform computes
+//
+//	dst[k] = sum_t src[t*stride] w^(k t)
+//
+// for k, t = 0, ..., n-1.
+// The slices dst and src must not overlap.
+func (p *fftPlan) Transform(dst, src []complex128, stride int) {
+	if p.n == 0 {
+		return
+	}
+	p.rec(dst[:p.n], src, p.n, stride, 1)
+}
+
+// Computes the transform of length n of src[t*stride] into dst.
+// The twiddle factors of length n are every step-th element of p.w.
+func (p *fftPlan) rec(dst, src []complex128, n, stride, step int) {
+	if n == 1 {
+		dst[0] = src[0]
+		return
+	}
+	r := smallestFactor(n)
+	m := n / r
+	// Transform the r interleaved sub-sequences of length m.
+	for q := 0; q < r; q++ {
+		p.rec(dst[q*m:(q+1)*m], src[q*stride:], m, stride*r, step*r)
+	}
+	// Combine the sub-sequences.
+	if r == 2 {
+		for k := 0; k < m; k++ {
+			a, b := dst[k], p.w[k*step]*dst[m+k]
+			dst[k], dst[m+k] = a+b, a-b
+		}
+		return
+	}
+	for k := 0; k < m; k++ {
+		for j := 0; j < r; j++ {
+			// Index of output element.
+			u := k + j*m
+			var sum complex128
+			for q := 0; q < r; q++ {
+				sum += p.w[(q*u*step)%p.n] * dst[q*m+k]
+			}
+			p.tmp[j] = sum
+		}
+		for j := 0; j < r; j++ {
+			dst[k+j*m] = p.tmp[j]
+		}
+	}
+}
+
+// Returns the smallest prime factor of n > 1.
+func smallestFactor(n int) int {
+	for _, k := range primes {
+		if n%k == 0 {
+			return k
+		}
+	}
+	for k := primes[len(primes)-1] + 2; k*k <= n; k += 2 {
+		if n%k == 0 {
+			return k
+		}
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package slide
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+func TestFFTPlan_vsNaive(t *testing.T) {
+	const eps = 1e-9
+	for _, n := range []int{1, 2, 3, 4, 6, 7, 8, 12, 30, 49, 11, 13, 22, 64, 105} {
+		for _, dir := range []direction{forward, backward} {
+			// Use a stride to check strided access.
+			const stride = 3
+			src := make([]complex128, n*stride)
+			for i := range src {
+				src[i] = complex(rand.NormFloat64(), rand.NormFloat64())
+			}
+			want := naiveDFT(src, n, stride, dir)
+			got := make([]complex128, n)
+			newFFTPlan(n, dir).Transform(got, src, stride)
+			for k := range want {
+				if cmplx.Abs(want[k]-got[k]) > eps*math.Max(1, cmplx.Abs(want[k])) {
+					t.Errorf("length %d, direction %d: different at %d: want %v, got %v", n, dir, k, want[k], got[k])
+					break
+				}
+			}
+		}
+	}
+}
+
+func naiveDFT(src []complex128, n, stride int, dir direction) []complex128 {
+	sign := -1.0
+	if dir == backward {
+		sign = 1
+	}
+	dst := make([]complex128, n)
+	for k := range dst {
+		for u := 0; u < n; u++ {
+			theta := sign * 2 * math.Pi * float64(k*u%n) / float64(n)
+			dst[k] += cmplx.Rect(1, theta) * src[u*stride]
+		}
+	}
+	return dst
+}
@@ -1,6 +1,7 @@
 package slide
 
 import (
+	"context"
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
@@ -15,12 +16,22 @@ type ScoreFunc func(*rimg64.Multi) (float64, error)
 // If the window size is larger than the image size in either dimension,
 // a nil image is returned with no error.
 func EvalFunc(im *rimg64.Multi, size image.Point, f ScoreFunc) (*rimg64.Image, error) {
+	return EvalFuncContext(context.Background(), im, size, f)
+}
+
+// EvalFuncContext is like EvalFunc but returns the error of the context
+// if it is done before every window has been evaluated.
+// The context is checked once per column of windows.
+func EvalFuncContext(ctx context.Context, im *rimg64.Multi, size image.Point, f ScoreFunc) (*rimg64.Image, error) {
 	if im.Width < size.X || im.Height < size.Y {
 		return nil, nil
 	}
 	r := rimg64.New(im.Width-size.X+1, im.Height-size.Y+1)
 	x := rimg64.NewMulti(size.X, size.Y, im.Channels)
 	for i := 0; i < r.Width; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		for j := 0; j < r.Height; j++ {
 			// Copy window into x.
 			for u := 0; u < size.X; u++ {
@@ -0,0 +1,231 @@
+package slide
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/jvlmdr/go-cv/rimg64"
+)
+
+// ScorerList is a list of scorers.
+type ScorerList interface {
+	Len() int
+	At(int) Scorer
+}
+
+// SliderList is a ScorerList that has an efficient method
+// for evaluating every scorer in sliding window fashion.
+type SliderList interface {
+	ScorerList
+	Slide(*rimg64.Multi) ([]*rimg64.Image, error)
+}
+
+// ScorerSlice satisfies ScorerList.
+type ScorerSlice []Scorer
+
+func (s ScorerSlice) Len() int        { return len(s) }
+func (s ScorerSlice) At(i int) Scorer { return s[i] }
+
+// ScoreList computes the score of every window for every scorer.
+// If list is a SliderList, then its Slide() function is called.
+// Otherwise Score() is called for each element.
+//
+// The response of element i is at index i.
+// The response is nil if the image is smaller than the scorer.
+func ScoreList(im *rimg64.Multi, list ScorerList) ([]*rimg64.Image, error) {
+	if slider, ok := list.(SliderList); ok {
+		return slider.Slide(im)
+	}
+	resps := make([]*rimg64.Image, list.Len())
+	for i := range resps {
+		resp, err := Score(im, list.At(i))
+		if err != nil {
+			return nil, err
+		}
+		resps[i] = resp
+	}
+	return resps, nil
+}
+
+// AffineList evaluates a list of affine scorers on the same image.
+//
+// The Fourier transform of each channel of the image
+// is computed once and shared by all templates.
+// FFT plans and buffers are cached for each transform size,
+// therefore the same list should be used for every level of a pyramid.
+// Call Destroy to release the plans.
+//
+// Slide may be called concurrently.
+// Calls which use the same transform size are serialized.
+type AffineList struct {
+	Scorers []*AffineScorer
+
+	mu   sync.Mutex
+	work map[image.Point]*affineWork
+}
+
+// NewAffineList creates a list of affine scorers.
+// All templates must have the same number of channels
+// and use the Dot operation.
+func NewAffineList(scorers []*AffineScorer) (*AffineList, error) {
+	for i, s := range scorers {
+		if s.Op != Dot {
+			return nil, fmt.Errorf("scorer %d: operation is not dot product", i)
+		}
+		if s.Tmpl.Channels != scorers[0].Tmpl.Channels {
+			return nil, fmt.Errorf("different channels: scorer 0 has %d, scorer %d has %d",
+				scorers[0].Tmpl.Channels, i, s.Tmpl.Channels)
+		}
+	}
+	return &AffineList{Scorers: scorers}, nil
+}
+
+func (l *AffineList) Len() int        { return len(l.Scorers) }
+func (l *AffineList) At(i int) Scorer { return l.Scorers[i] }
+
+// Slide computes the response of every template to the image.
+// The bias of each scorer is added to its response.
+func (l *AffineList) Slide(im *rimg64.Multi) ([]*rimg64.Image, error) {
+	resps := make([]*rimg64.Image, len(l.Scorers))
+	if len(l.Scorers) == 0 {
+		return resps, nil
+	}
+	if im.Channels != l.Scorers[0].Tmpl.Channels {
+		return nil, fmt.Errorf("different channels: image %d, templates %d", im.Channels, l.Scorers[0].Tmpl.Channels)
+	}
+	work, fftMuls := FFT2Size(im.Size())
+	// Image transform is shared, only need to perform
+	// one forward and one inverse transform per template.
+	fftMuls *= 2
+
+	var w *affineWork
+	for i, s := range l.Scorers {
+		g := s.Tmpl
+		size := ValidSize(im.Size(), g.Size())
+		if size.X <= 0 || size.Y <= 0 {
+			continue
+		}
+		var (
+			y   *rimg64.Image
+			err error
+		)
+		naiveMuls := size.X * size.Y * g.Width * g.Height
+		if naiveMuls <= fftMuls {
+			y, err = CorrMultiNaive(im, g)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			if w == nil {
+				// Transform image on first use.
+				w = l.workFor(work, im.Channels)
+				w.Lock()
+				defer w.Unlock()
+				w.setImage(im)
+			}
+			y = w.corr(g, size)
+		}
+		if s.Bias != 0 {
+			for u := 0; u < y.Width; u++ {
+				for v := 0; v < y.Height; v++ {
+					y.Set(u, v, y.At(u, v)+s.Bias)
+				}
+			}
+		}
+		resps[i] = y
+	}
+	return resps, nil
+}
+
+// Destroy releases the cached FFT plans.
+// The list must not be in use.
+func (l *AffineList) Destroy() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, w := range l.work {
+		w.destroy()
+	}
+	l.work = nil
+}
+
+// Returns the cached buffers for the given transform size,
+// creating them if they do not exist.
+func (l *AffineList) workFor(size image.Point, channels int) *affineWork {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.work == nil {
+		l.work = make(map[image.Point]*affineWork)
+	}
+	w, ok := l.work[size]
+	if !ok {
+		w = newAffineWork(size, channels)
+		l.work[size] = w
+	}
+	return w
+}
+
+// Buffers and plans for evaluating templates
+// at one transform size.
+type affineWork struct {
+	sync.Mutex
+	// Transform of each channel of the image.
+	fhat []*array2
+	ffwd []*plan
+	// Transform of the current template channel.
+	curr *array2
+	gfwd *plan
+	// Sum over channels in the Fourier domain.
+	sum *array2
+	bwd *plan
+}
+
+func newAffineWork(size image.Point, channels int) *affineWork {
+	w := new(affineWork)
+	w.fhat = make([]*array2, channels)
+	w.ffwd = make([]*plan, channels)
+	for p := range w.fhat {
+		w.fhat[p] = newArray2(size.X, size.Y)
+		w.ffwd[p] = newPlan2(w.fhat[p], forward)
+	}
+	w.curr = newArray2(size.X, size.Y)
+	w.gfwd = newPlan2(w.curr, forward)
+	w.sum = newArray2(size.X, size.Y)
+	w.bwd = newPlan2(w.sum, backward)
+	return w
+}
+
+// Takes the transform of every channel of the image.
+func (w *affineWork) setImage(f *rimg64.Multi) {
+	for p := range w.fhat {
+		copyChannelTo(w.fhat[p], f, p)
+		w.ffwd[p].Execute()
+	}
+}
+
+// Computes the correlation of the current image with g.
+// The output has the given size.
+func (w *affineWork) corr(g *rimg64.Multi, size image.Point) *rimg64.Image {
+	m, n := w.sum.Dims()
+	alpha := complex(1/float64(m*n), 0)
+	zero(w.sum)
+	for p := range w.fhat {
+		// h[x] = (G_p corr F_p)[x]
+		// H[x] = conj(G_p[x]) F_p[x]
+		copyChannelTo(w.curr, g, p)
+		w.gfwd.Execute()
+		addScaleMul(w.sum, alpha, w.curr, w.fhat[p])
+	}
+	w.bwd.Execute()
+	h := rimg64.New(size.X, size.Y)
+	copyRealTo(h, w.sum)
+	return h
+}
+
+func (w *affineWork) destroy() {
+	for _, p := range w.ffwd {
+		p.Destroy()
+	}
+	w.gfwd.Destroy()
+	w.bwd.Destroy()
+}
@@ -0,0 +1,136 @@
+package slide_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/slide"
+)
+
+func TestAffineList_Slide(t *testing.T) {
+	const (
+		channels = 4
+		eps      = 1e-9
+	)
+	// Mixture of small and large templates.
+	// The last template is larger than some images.
+	sizes := [][2]int{{3, 3}, {12, 8}, {30, 20}, {60, 50}}
+	scorers := make([]*slide.AffineScorer, len(sizes))
+	for i, size := range sizes {
+		tmpl := randMulti(size[0], size[1], channels)
+		scorers[i] = &slide.AffineScorer{Tmpl: tmpl, Bias: rand.NormFloat64()}
+	}
+	list, err := slide.NewAffineList(scorers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer list.Destroy()
+
+	// Repeat some sizes to use cached plans.
+	ims := [][2]int{{100, 80}, {50, 40}, {100, 80}, {73, 61}}
+	for _, size := range ims {
+		f := randMulti(size[0], size[1], channels)
+		resps, err := list.Slide(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resps) != len(scorers) {
+			t.Fatalf("wrong number of responses: want %d, got %d", len(scorers), len(resps))
+		}
+		for i, scorer := range scorers {
+			want, err := scorer.Slide(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want == nil {
+				if resps[i] != nil {
+					t.Errorf("image %v, template %d: expected nil response", f.Size(), i)
+				}
+				continue
+			}
+			if resps[i] == nil {
+				t.Errorf("image %v, template %d: unexpected nil response", f.Size(), i)
+				continue
+			}
+			if err := errIfNotEqImage(want, resps[i], eps); err != nil {
+				t.Errorf("image %v, template %d: %v", f.Size(), i, err)
+			}
+		}
+	}
+}
+
+func TestScoreList(t *testing.T) {
+	const (
+		channels = 3
+		eps      = 1e-9
+	)
+	scorers := []*slide.AffineScorer{
+		{Tmpl: randMulti(5, 4, channels), Bias: 1},
+		{Tmpl: randMulti(20, 30, channels), Bias: -2},
+	}
+	list, err := slide.NewAffineList(scorers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer list.Destroy()
+	f := randMulti(64, 48, channels)
+	// Compare the joint evaluation to a plain list.
+	got, err := slide.ScoreList(f, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := slide.ScoreList(f, slide.ScorerSlice{scorers[0], scorers[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if err := errIfNotEqImage(want[i], got[i], eps); err != nil {
+			t.Errorf("template %d: %v", i, err)
+		}
+	}
+}
+
+func TestNewAffineList_channels(t *testing.T) {
+	scorers := []*slide.AffineScorer{
+		{Tmpl: randMulti(5, 4, 3)},
+		{Tmpl: randMulti(5, 4, 2)},
+	}
+	if _, err := slide.NewAffineList(scorers); err == nil {
+		t.Fatal("expected error for different channels")
+	}
+}
+
+func BenchmarkAffineList_Slide_Im_160x120_Tmpl_12x8_Num_16(b *testing.B) {
+	benchmarkAffineList(b, 160, 120, 12, 8, 31, 16, false)
+}
+
+func BenchmarkAffineScorer_Slide_Im_160x120_Tmpl_12x8_Num_16(b *testing.B) {
+	benchmarkAffineList(b, 160, 120, 12, 8, 31, 16, true)
+}
+
+func benchmarkAffineList(b *testing.B, w, h, m, n, c, num int, separate bool) {
+	f := randMulti(w, h, c)
+	scorers := make([]*slide.AffineScorer, num)
+	for i := range scorers {
+		scorers[i] = &slide.AffineScorer{Tmpl: randMulti(m, n, c)}
+	}
+	list, err := slide.NewAffineList(scorers)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer list.Destroy()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if separate {
+			for _, s := range scorers {
+				if _, err := s.Slide(f); err != nil {
+					b.Fatal(err)
+				}
+			}
+			continue
+		}
+		if _, err := list.Slide(f); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
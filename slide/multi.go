@@ -4,7 +4,6 @@ import (
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 	"github.com/jvlmdr/lin-go/blas"
 )
 
@@ -81,13 +80,13 @@ func CorrMultiFFT(f, g *rimg64.Multi) (*rimg64.Image, error) {
 		return nil, nil
 	}
 	work, _ := FFT2Size(f.Size())
-	fhat := fftw.NewArray2(work.X, work.Y)
-	ghat := fftw.NewArray2(work.X, work.Y)
-	ffwd := fftw.NewPlan2(fhat, fhat, fftw.Forward, fftw.Estimate)
+	fhat := newArray2(work.X, work.Y)
+	ghat := newArray2(work.X, work.Y)
+	ffwd := newPlan2(fhat, forward)
 	defer ffwd.Destroy()
-	gfwd := fftw.NewPlan2(ghat, ghat, fftw.Forward, fftw.Estimate)
+	gfwd := newPlan2(ghat, forward)
 	defer gfwd.Destroy()
-	hhat := fftw.NewArray2(work.X, work.Y)
+	hhat := newArray2(work.X, work.Y)
 	for p := 0; p < f.Channels; p++ {
 		// Take transform of each channel.
 		copyChannelTo(fhat, f, p)
@@ -98,7 +97,7 @@ func CorrMultiFFT(f, g *rimg64.Multi) (*rimg64.Image, error) {
 	}
 	n := float64(work.X * work.Y)
 	scale(complex(1/n, 0), hhat)
-	fftw.IFFT2To(hhat, hhat)
+	ifft2(hhat)
 	h := rimg64.New(out.X, out.Y)
 	copyRealTo(h, hhat)
 	return h, nil
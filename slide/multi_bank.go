@@ -4,7 +4,6 @@ import (
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 	"github.com/jvlmdr/lin-go/blas"
 )
 
@@ -60,18 +59,18 @@ func CorrMultiBankFFT(f *rimg64.Multi, g *MultiBank) (*rimg64.Multi, error) {
 	// Determine optimal size for FFT.
 	work, _ := FFT2Size(f.Size())
 	// Cache FFT of each channel of image.
-	fhat := make([]*fftw.Array2, f.Channels)
+	fhat := make([]*array2, f.Channels)
 	for i := range fhat {
-		fhat[i] = fftw.NewArray2(work.X, work.Y)
+		fhat[i] = newArray2(work.X, work.Y)
 		copyChannelTo(fhat[i], f, i)
-		fftw.FFT2To(fhat[i], fhat[i])
+		fft2(fhat[i])
 	}
 
-	curr := fftw.NewArray2(work.X, work.Y)
-	fwd := fftw.NewPlan2(curr, curr, fftw.Forward, fftw.Estimate)
+	curr := newArray2(work.X, work.Y)
+	fwd := newPlan2(curr, forward)
 	defer fwd.Destroy()
-	sum := fftw.NewArray2(work.X, work.Y)
-	bwd := fftw.NewPlan2(sum, sum, fftw.Backward, fftw.Estimate)
+	sum := newArray2(work.X, work.Y)
+	bwd := newPlan2(sum, backward)
 	defer bwd.Destroy()
 
 	h := rimg64.NewMulti(out.X, out.Y, len(g.Filters))
@@ -4,7 +4,6 @@ import (
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 	"github.com/jvlmdr/lin-go/blas"
 )
 
@@ -60,21 +59,21 @@ func CorrMultiBankStrideFFT(f *rimg64.Multi, g *MultiBank, stride int) (*rimg64.
 	work, _ := FFT2Size(fsub)
 	// Cache FFT of each channel of image for convolving with multiple filters.
 	// Re-use plan for multiple convolutions too.
-	fhat := make([]*fftw.Array2, f.Channels)
-	ffwd := make([]*fftw.Plan, f.Channels)
+	fhat := make([]*array2, f.Channels)
+	ffwd := make([]*plan, f.Channels)
 	for k := range fhat {
-		fhat[k] = fftw.NewArray2(work.X, work.Y)
-		ffwd[k] = fftw.NewPlan2(fhat[k], fhat[k], fftw.Forward, fftw.Estimate)
+		fhat[k] = newArray2(work.X, work.Y)
+		ffwd[k] = newPlan2(fhat[k], forward)
 		defer ffwd[k].Destroy()
 	}
 	// FFT for current filter.
-	curr := fftw.NewArray2(work.X, work.Y)
-	gfwd := fftw.NewPlan2(curr, curr, fftw.Forward, fftw.Estimate)
+	curr := newArray2(work.X, work.Y)
+	gfwd := newPlan2(curr, forward)
 	defer gfwd.Destroy()
 	// Allocate one array per output channel.
-	hhat := make([]*fftw.Array2, len(g.Filters))
+	hhat := make([]*array2, len(g.Filters))
 	for k := range hhat {
-		hhat[k] = fftw.NewArray2(work.X, work.Y)
+		hhat[k] = newArray2(work.X, work.Y)
 	}
 	// Normalization factor.
 	alpha := complex(1/float64(work.X*work.Y), 0)
@@ -99,7 +98,7 @@ func CorrMultiBankStrideFFT(f *rimg64.Multi, g *MultiBank, stride int) (*rimg64.
 	h := rimg64.NewMulti(out.X, out.Y, len(g.Filters))
 	for q := range hhat {
 		scale(alpha, hhat[q])
-		fftw.IFFT2To(hhat[q], hhat[q])
+		ifft2(hhat[q])
 		copyRealToChannel(h, q, hhat[q])
 	}
 	return h, nil
@@ -4,7 +4,6 @@ import (
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 	"github.com/jvlmdr/lin-go/blas"
 )
 
@@ -63,16 +62,16 @@ func CorrMultiStrideFFT(f, g *rimg64.Multi, stride int) (*rimg64.Image, error) {
 	work, _ := FFT2Size(fsub)
 	// Cache FFT of each channel of image for convolving with multiple filters.
 	// Re-use plan for multiple convolutions too.
-	fhat := fftw.NewArray2(work.X, work.Y)
-	ffwd := fftw.NewPlan2(fhat, fhat, fftw.Forward, fftw.Estimate)
+	fhat := newArray2(work.X, work.Y)
+	ffwd := newPlan2(fhat, forward)
 	defer ffwd.Destroy()
-	ghat := fftw.NewArray2(work.X, work.Y)
-	gfwd := fftw.NewPlan2(ghat, ghat, fftw.Forward, fftw.Estimate)
+	ghat := newArray2(work.X, work.Y)
+	gfwd := newPlan2(ghat, forward)
 	defer gfwd.Destroy()
 	// Normalization factor.
 	alpha := complex(1/float64(work.X*work.Y), 0)
 	// Add the convolutions over channels and strides.
-	hhat := fftw.NewArray2(work.X, work.Y)
+	hhat := newArray2(work.X, work.Y)
 	for k := 0; k < f.Channels; k++ {
 		for i := 0; i < grid.X; i++ {
 			for j := 0; j < grid.Y; j++ {
@@ -88,7 +87,7 @@ func CorrMultiStrideFFT(f, g *rimg64.Multi, stride int) (*rimg64.Image, error) {
 	// Take the inverse transform.
 	h := rimg64.New(out.X, out.Y)
 	scale(alpha, hhat)
-	fftw.IFFT2To(hhat, hhat)
+	ifft2(hhat)
 	copyRealTo(h, hhat)
 	return h, nil
 }
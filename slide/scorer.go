@@ -1,6 +1,7 @@
 package slide
 
 import (
+	"context"
 	"image"
 
 	"github.com/jvlmdr/go-cv/rimg64"
@@ -22,6 +23,15 @@ type Slider interface {
 // Score computes the score of every window.
 // If scorer is a Slider, then its Slide() function is called.
 func Score(im *rimg64.Multi, scorer Scorer) (*rimg64.Image, error) {
+	return ScoreContext(context.Background(), im, scorer)
+}
+
+// ScoreContext is like Score but returns the error of the context if it is done.
+// A Slider is evaluated in one call and is only interrupted before it starts.
+func ScoreContext(ctx context.Context, im *rimg64.Multi, scorer Scorer) (*rimg64.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	var err error
 	var resp *rimg64.Image
 	if slider, ok := scorer.(Slider); ok {
@@ -30,20 +40,10 @@ func Score(im *rimg64.Multi, scorer Scorer) (*rimg64.Image, error) {
 			return nil, err
 		}
 	} else {
-		resp, err = EvalFunc(im, scorer.Size(), scorer.Score)
+		resp, err = EvalFuncContext(ctx, im, scorer.Size(), scorer.Score)
 		if err != nil {
 			return nil, err
 		}
 	}
 	return resp, nil
 }
-
-// It could be useful to move beyond simply Scorer and Slider.
-// For example, to evaluate multiple detectors on the same image
-// the Fourier transform and integral images could be re-used.
-
-//	type SliderList interface {
-//		Len() int
-//		At(int) Scorer
-//		Slide(*rimg64.Multi) ([]*rimg64.Image, error)
-//	}
@@ -6,10 +6,9 @@ import (
 	"math/cmplx"
 
 	"github.com/jvlmdr/go-cv/rimg64"
-	"github.com/jvlmdr/go-fftw/fftw"
 )
 
-func copyImageTo(x *fftw.Array2, f *rimg64.Image) {
+func copyImageTo(x *array2, f *rimg64.Image) {
 	w, h := x.Dims()
 	for u := 0; u < w; u++ {
 		for v := 0; v < h; v++ {
@@ -24,7 +23,7 @@ func copyImageTo(x *fftw.Array2, f *rimg64.Image) {
 
 // Assumes that f is no smaller than x.
 // Pads with zeros.
-func copyChannelTo(x *fftw.Array2, f *rimg64.Multi, p int) {
+func copyChannelTo(x *array2, f *rimg64.Multi, p int) {
 	w, h := x.Dims()
 	for u := 0; u < w; u++ {
 		for v := 0; v < h; v++ {
@@ -38,7 +37,7 @@ func copyChannelTo(x *fftw.Array2, f *rimg64.Multi, p int) {
 }
 
 // Assumes that f is no smaller than x.
-func copyRealTo(f *rimg64.Image, x *fftw.Array2) {
+func copyRealTo(f *rimg64.Image, x *array2) {
 	for u := 0; u < f.Width; u++ {
 		for v := 0; v < f.Height; v++ {
 			f.Set(u, v, real(x.At(u, v)))
@@ -47,7 +46,7 @@ func copyRealTo(f *rimg64.Image, x *fftw.Array2) {
 }
 
 // Assumes that f is no smaller than x.
-func copyRealToChannel(f *rimg64.Multi, p int, x *fftw.Array2) {
+func copyRealToChannel(f *rimg64.Multi, p int, x *array2) {
 	for u := 0; u < f.Width; u++ {
 		for v := 0; v < f.Height; v++ {
 			f.Set(u, v, p, real(x.At(u, v)))
@@ -57,7 +56,7 @@ func copyRealToChannel(f *rimg64.Multi, p int, x *fftw.Array2) {
 
 // dst[i, j] = src[i*stride + offset.X, j*stride + offset.Y],
 // or zero if this is outside the boundary.
-func copyStrideTo(dst *fftw.Array2, src *rimg64.Image, stride int, offset image.Point) {
+func copyStrideTo(dst *array2, src *rimg64.Image, stride int, offset image.Point) {
 	m, n := dst.Dims()
 	bnds := image.Rect(0, 0, src.Width, src.Height)
 	for i := 0; i < m; i++ {
@@ -74,7 +73,7 @@ func copyStrideTo(dst *fftw.Array2, src *rimg64.Image, stride int, offset image.
 
 // dst[i, j] = src[i*stride + offset.X, j*stride + offset.Y],
 // or zero if this is outside the boundary.
-func copyChannelStrideTo(dst *fftw.Array2, src *rimg64.Multi, channel, stride int, offset image.Point) {
+func copyChannelStrideTo(dst *array2, src *rimg64.Multi, channel, stride int, offset image.Point) {
 	m, n := dst.Dims()
 	bnds := image.Rect(0, 0, src.Width, src.Height)
 	for i := 0; i < m; i++ {
@@ -90,7 +89,7 @@ func copyChannelStrideTo(dst *fftw.Array2, src *rimg64.Multi, channel, stride in
 }
 
 // ci <- ci + k conj(ai) bi
-func addScaleMul(c *fftw.Array2, k complex128, a, b *fftw.Array2) {
+func addScaleMul(c *array2, k complex128, a, b *array2) {
 	m, n := a.Dims()
 	for i := 0; i < m; i++ {
 		for j := 0; j < n; j++ {
@@ -103,7 +102,7 @@ func addScaleMul(c *fftw.Array2, k complex128, a, b *fftw.Array2) {
 }
 
 // ci <- k conj(ai) bi
-func scaleMul(c *fftw.Array2, k complex128, a, b *fftw.Array2) {
+func scaleMul(c *array2, k complex128, a, b *array2) {
 	m, n := a.Dims()
 	for i := 0; i < m; i++ {
 		for j := 0; j < n; j++ {
@@ -115,7 +114,7 @@ func scaleMul(c *fftw.Array2, k complex128, a, b *fftw.Array2) {
 }
 
 // ai <- k ai
-func scale(k complex128, c *fftw.Array2) {
+func scale(k complex128, c *array2) {
 	m, n := c.Dims()
 	for i := 0; i < m; i++ {
 		for j := 0; j < n; j++ {
@@ -125,7 +124,7 @@ func scale(k complex128, c *fftw.Array2) {
 }
 
 // ci <- ci + conj(ai) bi
-func addMul(c *fftw.Array2, a, b *fftw.Array2) {
+func addMul(c *array2, a, b *array2) {
 	m, n := a.Dims()
 	for i := 0; i < m; i++ {
 		for j := 0; j < n; j++ {
@@ -137,7 +136,7 @@ func addMul(c *fftw.Array2, a, b *fftw.Array2) {
 	}
 }
 
-func zero(x *fftw.Array2) {
+func zero(x *array2) {
 	m, n := x.Dims()
 	for i := 0; i < m; i++ {
 		for j := 0; j < n; j++ {
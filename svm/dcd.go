@@ -0,0 +1,146 @@
+package svm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Opts specifies the parameters to Train().
+type Opts struct {
+	// Penalty for violating the margin.
+	// With s = BiasFeat, the objective is
+	// 	1/2 ||w||^2 + 1/2 b^2 / s^2 + C sum_i max(0, 1 - y_i (w' x_i + b)).
+	C float64
+	// The bias is the weight of a constant feature with this value
+	// multiplied by the value, as in LIBLINEAR.
+	// The bias is therefore regularized:
+	// larger values reduce the regularization of the bias.
+	// If zero, then the bias is zero.
+	BiasFeat float64
+	// Stop when the violation of the optimality conditions is below Tol.
+	Tol float64
+	// Maximum number of passes over the examples.
+	MaxIter int
+	// Seed for the order in which examples are visited.
+	Seed int64
+}
+
+// DefaultOpts returns the parameters which LIBLINEAR uses by default.
+func DefaultOpts() Opts {
+	return Opts{C: 1, BiasFeat: 1, Tol: 0.1, MaxIter: 1000}
+}
+
+// Result is the solution of Train().
+type Result struct {
+	W    []float64
+	Bias float64
+	// Dual variables of each example.
+	Alpha []float64
+	// Number of passes over the examples.
+	Iter int
+	// Whether the tolerance was attained.
+	Converged bool
+}
+
+// Train learns a linear classifier from labelled vectors.
+// Labels must be +1 or -1.
+//
+// Each pass visits the examples in random order.
+func Train(x [][]float64, y []float64, opts Opts) (*Result, error) {
+	if len(x) != len(y) {
+		return nil, fmt.Errorf("different number of examples and labels: %d, %d", len(x), len(y))
+	}
+	if len(x) == 0 {
+		return nil, errors.New("no examples")
+	}
+	if !(opts.C > 0) {
+		return nil, fmt.Errorf("C must be positive: %g", opts.C)
+	}
+	dim := len(x[0])
+	for i := range x {
+		if len(x[i]) != dim {
+			return nil, fmt.Errorf("example %d: different dimension: %d, %d", i, len(x[i]), dim)
+		}
+		if y[i] != 1 && y[i] != -1 {
+			return nil, fmt.Errorf("example %d: label is not +1 or -1: %g", i, y[i])
+		}
+	}
+	n := len(x)
+	c := opts.C
+	s := opts.BiasFeat
+
+	// Diagonal of Gram matrix.
+	q := make([]float64, n)
+	for i, xi := range x {
+		q[i] = dot(xi, xi) + s*s
+	}
+	var (
+		w     = make([]float64, dim)
+		wb    float64
+		alpha = make([]float64, n)
+		r     = rand.New(rand.NewSource(opts.Seed))
+		res   = &Result{Alpha: alpha}
+	)
+	for res.Iter < opts.MaxIter {
+		res.Iter++
+		// Range of projected gradient.
+		pgMax, pgMin := math.Inf(-1), math.Inf(1)
+		for _, i := range r.Perm(n) {
+			if q[i] == 0 {
+				continue
+			}
+			g := y[i]*(dot(w, x[i])+wb*s) - 1
+			var pg float64
+			switch {
+			case alpha[i] == 0:
+				pg = math.Min(g, 0)
+			case alpha[i] == c:
+				pg = math.Max(g, 0)
+			default:
+				pg = g
+			}
+			pgMax = math.Max(pgMax, pg)
+			pgMin = math.Min(pgMin, pg)
+			if pg == 0 {
+				continue
+			}
+			prev := alpha[i]
+			alpha[i] = math.Min(math.Max(alpha[i]-g/q[i], 0), c)
+			delta := (alpha[i] - prev) * y[i]
+			for j, xij := range x[i] {
+				w[j] += delta * xij
+			}
+			wb += delta * s
+		}
+		if pgMax-pgMin < opts.Tol {
+			res.Converged = true
+			break
+		}
+	}
+	res.W = w
+	res.Bias = wb * s
+	return res, nil
+}
+
+// Primal returns the value of the primal objective.
+func Primal(x [][]float64, y []float64, w []float64, bias, c, biasFeat float64) float64 {
+	f := 0.5 * dot(w, w)
+	if biasFeat != 0 {
+		wb := bias / biasFeat
+		f += 0.5 * wb * wb
+	}
+	for i := range x {
+		f += c * math.Max(0, 1-y[i]*(dot(w, x[i])+bias))
+	}
+	return f
+}
+
+func dot(x, y []float64) float64 {
+	var s float64
+	for i := range x {
+		s += x[i] * y[i]
+	}
+	return s
+}
@@ -0,0 +1,76 @@
+package svm_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/svm"
+)
+
+// Generates two overlapping Gaussian clusters.
+func randProblem(n, dim int, r *rand.Rand) ([][]float64, []float64) {
+	x := make([][]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		y[i] = 1
+		if i%2 == 1 {
+			y[i] = -1
+		}
+		x[i] = make([]float64, dim)
+		for j := range x[i] {
+			x[i][j] = r.NormFloat64() + y[i]*0.5 + 1
+		}
+	}
+	return x, y
+}
+
+func TestTrain_optimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	x, y := randProblem(200, 5, r)
+	for _, biasFeat := range []float64{0, 1, 10} {
+		opts := svm.Opts{C: 0.5, BiasFeat: biasFeat, Tol: 1e-6, MaxIter: 10000}
+		res, err := svm.Train(x, y, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Converged {
+			t.Fatalf("bias feature %g: did not converge", biasFeat)
+		}
+		if biasFeat == 0 && res.Bias != 0 {
+			t.Errorf("bias feature 0: non-zero bias %g", res.Bias)
+		}
+		// Duality gap must vanish at the optimum.
+		primal := svm.Primal(x, y, res.W, res.Bias, opts.C, opts.BiasFeat)
+		var dual float64
+		for _, a := range res.Alpha {
+			dual += a
+		}
+		dual -= 0.5 * dot(res.W, res.W)
+		if biasFeat != 0 {
+			wb := res.Bias / biasFeat
+			dual -= 0.5 * wb * wb
+		}
+		if gap := primal - dual; math.Abs(gap) > 1e-3*math.Abs(primal) {
+			t.Errorf("bias feature %g: duality gap %g (primal %g, dual %g)", biasFeat, gap, primal, dual)
+		}
+	}
+}
+
+func TestTrain_labels(t *testing.T) {
+	x := [][]float64{{1}, {-1}}
+	if _, err := svm.Train(x, []float64{1, 0}, svm.DefaultOpts()); err == nil {
+		t.Error("expected error for label 0")
+	}
+	if _, err := svm.Train(x, []float64{1}, svm.DefaultOpts()); err == nil {
+		t.Error("expected error for wrong number of labels")
+	}
+}
+
+func dot(x, y []float64) float64 {
+	var s float64
+	for i := range x {
+		s += x[i] * y[i]
+	}
+	return s
+}
@@ -0,0 +1,8 @@
+/*
+Package svm trains linear support vector machines.
+
+The solver is the dual coordinate descent method of
+Hsieh, Chang, Lin, Keerthi and Sundararajan (2008)
+for the L1-loss (hinge loss) SVM.
+*/
+package svm
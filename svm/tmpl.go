@@ -0,0 +1,50 @@
+package svm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/slide"
+)
+
+// TrainTmpl learns an affine template from positive and negative feature windows.
+// All windows must have the same size and number of channels.
+// The shape is the pixel window from which the features were computed
+// and the position of the bounding box within it.
+func TrainTmpl(pos, neg []*rimg64.Multi, shape detect.PadRect, opts Opts) (*detect.FeatTmpl, error) {
+	if len(pos) == 0 || len(neg) == 0 {
+		return nil, errors.New("need positive and negative examples")
+	}
+	ref := pos[0]
+	var (
+		x [][]float64
+		y []float64
+	)
+	add := func(ims []*rimg64.Multi, label float64) error {
+		for _, im := range ims {
+			if !im.Size().Eq(ref.Size()) || im.Channels != ref.Channels {
+				return fmt.Errorf("different window: %v x %d, %v x %d", im.Size(), im.Channels, ref.Size(), ref.Channels)
+			}
+			x = append(x, im.Elems)
+			y = append(y, label)
+		}
+		return nil
+	}
+	if err := add(pos, 1); err != nil {
+		return nil, err
+	}
+	if err := add(neg, -1); err != nil {
+		return nil, err
+	}
+	res, err := Train(x, y, opts)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &rimg64.Multi{res.W, ref.Width, ref.Height, ref.Channels}
+	return &detect.FeatTmpl{
+		Scorer:     &slide.AffineScorer{Tmpl: tmpl, Bias: res.Bias},
+		PixelShape: shape,
+	}, nil
+}
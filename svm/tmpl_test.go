@@ -0,0 +1,63 @@
+package svm_test
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/jvlmdr/go-cv/detect"
+	"github.com/jvlmdr/go-cv/rimg64"
+	"github.com/jvlmdr/go-cv/svm"
+)
+
+func TestTrainTmpl(t *testing.T) {
+	const (
+		w, h, c = 4, 6, 3
+		n       = 50
+	)
+	r := rand.New(rand.NewSource(1))
+	// Positive windows have a bright center.
+	window := func(label float64) *rimg64.Multi {
+		f := rimg64.NewMulti(w, h, c)
+		for i := range f.Elems {
+			f.Elems[i] = r.NormFloat64()
+		}
+		for k := 0; k < c; k++ {
+			f.Set(w/2, h/2, k, f.At(w/2, h/2, k)+3*label)
+		}
+		return f
+	}
+	var pos, neg []*rimg64.Multi
+	for i := 0; i < n; i++ {
+		pos = append(pos, window(1))
+		neg = append(neg, window(-1))
+	}
+	shape := detect.PadRect{Size: image.Pt(32, 48), Int: image.Rect(8, 8, 24, 40)}
+	tmpl, err := svm.TrainTmpl(pos, neg, shape, svm.DefaultOpts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.PixelShape != shape {
+		t.Errorf("wrong shape: want %v, got %v", shape, tmpl.PixelShape)
+	}
+	if got := tmpl.Scorer.Size(); !got.Eq(image.Pt(w, h)) {
+		t.Fatalf("wrong size: want %v, got %v", image.Pt(w, h), got)
+	}
+	// Scorer should classify most training examples correctly.
+	var errs int
+	for i := range pos {
+		if s, _ := tmpl.Scorer.Score(pos[i]); s <= 0 {
+			errs++
+		}
+		if s, _ := tmpl.Scorer.Score(neg[i]); s >= 0 {
+			errs++
+		}
+	}
+	if errs > n/10 {
+		t.Errorf("too many training errors: %d of %d", errs, 2*n)
+	}
+
+	if _, err := svm.TrainTmpl(pos, []*rimg64.Multi{rimg64.NewMulti(w+1, h, c)}, shape, svm.DefaultOpts()); err == nil {
+		t.Error("expected error for different window size")
+	}
+}